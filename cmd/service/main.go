@@ -11,12 +11,22 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/dispatch"
 	"github.com/nikitkaralius/lineup/internal/handlers"
+	"github.com/nikitkaralius/lineup/internal/health"
+	"github.com/nikitkaralius/lineup/internal/permissions"
 	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/router"
+	"github.com/nikitkaralius/lineup/internal/schedules"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/updates"
 	"github.com/nikitkaralius/lineup/internal/voters"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
@@ -29,9 +39,22 @@ type config struct {
 	LogVerbose       bool
 	HTTPAddr         string
 	WebhookURL       string
-	Mode             string
+	// WebhookSecret is checked against the secret token header of webhook
+	// requests; required in webhook mode
+	WebhookSecret string
+	Mode          string
+	// Workers and QueueSize size the update dispatcher
+	Workers   int
+	QueueSize int
 }
 
+// drainTimeout bounds how long queued updates are handled after a shutdown
+// signal.
+const drainTimeout = 20 * time.Second
+
+// getMeCacheTTL is how long a getMe readiness result is reused.
+const getMeCacheTTL = 30 * time.Second
+
 func main() {
 	cfg := config{}
 	flag.StringVar(&cfg.DatabaseDSN, "dsn", "", "Postgres DB DSN (required)")
@@ -39,12 +62,15 @@ func main() {
 	flag.StringVar(&cfg.HTTPAddr, "http-addr", ":8080", "HTTP listen address (default :8080)")
 	flag.StringVar(&cfg.WebhookURL, "webhook-url", "", "Telegram webhook public URL (required for webhook mode)")
 	flag.StringVar(&cfg.Mode, "mode", "long-polling", "Bot update mode: long-polling or webhook (default long-polling)")
+	flag.IntVar(&cfg.Workers, "workers", 16, "Number of update handling workers (default 16)")
+	flag.IntVar(&cfg.QueueSize, "queue-size", 64, "Updates queued per worker before intake blocks (default 64)")
 	flag.Parse()
 
 	if cfg.DatabaseDSN == "" {
 		log.Fatal("database dsn is required")
 	}
 
+	cfg.WebhookSecret = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
 	cfg.TelegramBotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
 	if cfg.TelegramBotToken == "" {
 		log.Fatal("env TELEGRAM_BOT_TOKEN is required")
@@ -62,6 +88,7 @@ func main() {
 	}
 	me := bot.Self.UserName
 	log.Printf("Authorized on account @%s", me)
+	client := telegram.NewRateLimitedClient(bot, telegram.DefaultLimits)
 
 	dbPool, err := pgxpool.New(ctx, cfg.DatabaseDSN)
 	if err != nil {
@@ -71,6 +98,11 @@ func main() {
 
 	pollsRepo := polls.NewRepository(dbPool)
 	votersRepo := voters.NewRepository(dbPool)
+	chatsRepo := chats.NewRepository(dbPool)
+	schedulesRepo := schedules.NewRepository(dbPool)
+	states := conversations.NewPostgresStore(dbPool, conversations.DefaultTTL)
+	updatesRepo := updates.NewRepository(dbPool)
+	rolesRepo := permissions.NewRepository(dbPool)
 
 	riverClient, err := river.NewClient(riverpgxv5.New(dbPool), &river.Config{})
 	if err != nil {
@@ -78,7 +110,57 @@ func main() {
 	}
 	pollsService := polls.NewPollsService(riverClient)
 
+	perms := permissions.NewChecker(client, rolesRepo, chatsRepo, permissions.AdminCacheTTL)
+	updateRouter := router.New(me)
+	updateRouter.Use(router.Recover(), router.Logging(cfg.LogVerbose))
+	handlers.Register(updateRouter, handlers.Dependencies{
+		Bot:          client,
+		Polls:        pollsRepo,
+		Voters:       votersRepo,
+		Chats:        chatsRepo,
+		Schedules:    schedulesRepo,
+		Roles:        rolesRepo,
+		Permissions:  perms,
+		States:       states,
+		PollsService: pollsService,
+		BotUsername:  me,
+	})
+	if err := handlers.SetCommands(client); err != nil {
+		log.Printf("failed to set bot commands: %v", err)
+	}
+
+	handleUpdate := func(ctx context.Context, update tgbotapi.Update) {
+		// Telegram redelivers updates it thinks were lost; handle each once
+		first, err := updatesRepo.MarkSeen(ctx, update.UpdateID)
+		if err != nil {
+			log.Printf("dedup update %d error: %v", update.UpdateID, err)
+		} else if !first {
+			return
+		}
+		updateRouter.Handle(ctx, update)
+	}
+
+	dispatcher := dispatch.New(cfg.Workers, cfg.QueueSize, handleUpdate)
+	drain := func() {
+		drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		if err := dispatcher.Shutdown(drainCtx); err != nil {
+			log.Printf("failed to drain updates: %v", err)
+		}
+	}
+
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Live)
+	mux.HandleFunc("GET /readyz", health.Ready(map[string]health.Check{
+		"postgres": dbPool.Ping,
+		"telegram": health.Cached(func(context.Context) error {
+			_, err := bot.GetMe()
+			return err
+		}, getMeCacheTTL),
+	}))
+
+	// polling is closed once no more updates are dispatched
+	polling := make(chan struct{})
 
 	switch cfg.Mode {
 	case "webhook":
@@ -86,11 +168,12 @@ func main() {
 			log.Fatal("webhook-url is required in webhook mode")
 		}
 
-		wh, err := tgbotapi.NewWebhook(cfg.WebhookURL)
-		if err != nil {
-			log.Fatalf("failed to build webhook: %v", err)
+		// Every replica registers the webhook with this secret, so it must be
+		// shared rather than generated per process
+		if cfg.WebhookSecret == "" {
+			log.Fatal("env TELEGRAM_WEBHOOK_SECRET is required in webhook mode")
 		}
-		if _, err := bot.Request(wh); err != nil {
+		if err := telegram.SetWebhook(bot, cfg.WebhookURL, cfg.WebhookSecret); err != nil {
 			log.Fatalf("failed to set webhook: %v", err)
 		}
 		info, err := bot.GetWebhookInfo()
@@ -99,22 +182,23 @@ func main() {
 		}
 
 		mux.HandleFunc("POST /telegram/webhook", func(w http.ResponseWriter, r *http.Request) {
+			if !telegram.VerifySecretToken(r, cfg.WebhookSecret) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
 			var update tgbotapi.Update
 			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 				w.WriteHeader(http.StatusBadRequest)
 				return
 			}
-			if update.Message != nil {
-				handlers.HandleMessage(r.Context(), bot, pollsRepo, update.Message, me, pollsService)
-			}
-			if update.CallbackQuery != nil {
-				handlers.HandleCallback(r.Context(), bot, pollsRepo, votersRepo, update.CallbackQuery, me, pollsService)
-			}
-			if update.PollAnswer != nil {
-				handlers.HandlePollAnswer(r.Context(), votersRepo, update.PollAnswer)
+			// Acknowledge right away; a slow answer makes Telegram redeliver
+			if err := dispatcher.Dispatch(r.Context(), update); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
 			}
 			w.WriteHeader(http.StatusOK)
 		})
+		close(polling)
 	case "long-polling":
 		if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
 			log.Printf("failed to remove webhook (continuing): %v", err)
@@ -122,34 +206,26 @@ func main() {
 
 		u := tgbotapi.NewUpdate(0)
 		u.Timeout = 30
-		updates := bot.GetUpdatesChan(u)
+		updateCh := bot.GetUpdatesChan(u)
 		log.Printf("Started long polling with timeout=%d seconds", u.Timeout)
-		for {
-			select {
-			case <-ctx.Done():
-				bot.StopReceivingUpdates()
-				return
-			case update := <-updates:
-				if update.Message != nil {
-					handlers.HandleMessage(ctx, bot, pollsRepo, update.Message, me, pollsService)
-				}
-				if update.CallbackQuery != nil {
-					handlers.HandleCallback(ctx, bot, pollsRepo, votersRepo, update.CallbackQuery, me, pollsService)
-				}
-				if update.PollAnswer != nil {
-					handlers.HandlePollAnswer(ctx, votersRepo, update.PollAnswer)
+		go func() {
+			defer close(polling)
+			for {
+				select {
+				case <-ctx.Done():
+					bot.StopReceivingUpdates()
+					return
+				case update := <-updateCh:
+					if err := dispatcher.Dispatch(ctx, update); err != nil {
+						log.Printf("dropped update %d: %v", update.UpdateID, err)
+					}
 				}
 			}
-		}
+		}()
 	default:
 		log.Fatal("Unknown mode specified. See available options using --help")
 	}
 
-	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-
 	srv := &http.Server{Addr: cfg.HTTPAddr, Handler: mux}
 	go func() {
 		log.Printf("Service listening on %s", cfg.HTTPAddr)
@@ -162,4 +238,6 @@ func main() {
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctxShutdown)
+	<-polling
+	drain()
 }
@@ -6,15 +6,23 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/health"
 	"github.com/nikitkaralius/lineup/internal/jobs"
 	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/schedules"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/updates"
 	"github.com/nikitkaralius/lineup/internal/voters"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
@@ -24,12 +32,14 @@ type config struct {
 	TelegramBotToken string
 	DatabaseDSN      string
 	LogVerbose       bool
+	HTTPAddr         string
 }
 
 func main() {
 	cfg := config{}
 	flag.StringVar(&cfg.DatabaseDSN, "dsn", "", "Postgres DB DSN (required)")
 	flag.BoolVar(&cfg.LogVerbose, "verbose", false, "Enable verbose logging (default = false)")
+	flag.StringVar(&cfg.HTTPAddr, "http-addr", ":8081", "Health check listen address (default :8081)")
 	flag.Parse()
 
 	cfg.TelegramBotToken = os.Getenv("TELEGRAM_BOT_TOKEN")
@@ -50,30 +60,90 @@ func main() {
 
 	pollsRepo := polls.NewRepository(dbPool)
 	votersRepo := voters.NewRepository(dbPool)
+	chatsRepo := chats.NewRepository(dbPool)
+	schedulesRepo := schedules.NewRepository(dbPool)
+	states := conversations.NewPostgresStore(dbPool, conversations.DefaultTTL)
+	updatesRepo := updates.NewRepository(dbPool)
 
 	// Init Telegram bot for posting messages/results from workers
-	bot, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
+	api, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
 	if err != nil {
 		log.Fatal(err)
 	}
+	bot := telegram.NewRateLimitedClient(api, telegram.DefaultLimits)
 
 	workers := river.NewWorkers()
-	river.AddWorker(workers, jobs.NewFinishPollWorker(pollsRepo, votersRepo, bot))
+	river.AddWorker(workers, jobs.NewFinishPollWorker(pollsRepo, votersRepo, chatsRepo, bot))
+	river.AddWorker(workers, jobs.NewExpireSwapOfferWorker(votersRepo, chatsRepo, bot))
+	river.AddWorker(workers, jobs.NewRunSchedulesWorker(schedulesRepo, pollsRepo, chatsRepo, bot))
+	river.AddWorker(workers, jobs.NewCleanupConversationsWorker(states))
+	river.AddWorker(workers, jobs.NewReconcilePollsWorker(pollsRepo))
+	river.AddWorker(workers, jobs.NewCleanupUpdatesWorker(updatesRepo))
 
 	riverClient, err := river.NewClient(riverpgxv5.New(dbPool), &river.Config{
 		Queues: map[string]river.QueueConfig{
 			river.QueueDefault: {MaxWorkers: 100},
 		},
 		Workers: workers,
+		PeriodicJobs: []*river.PeriodicJob{
+			river.NewPeriodicJob(
+				river.PeriodicInterval(time.Minute),
+				func() (river.JobArgs, *river.InsertOpts) {
+					return schedules.RunSchedulesArgs{}, nil
+				},
+				&river.PeriodicJobOpts{RunOnStart: true},
+			),
+			river.NewPeriodicJob(
+				river.PeriodicInterval(time.Hour),
+				func() (river.JobArgs, *river.InsertOpts) {
+					return conversations.CleanupArgs{}, nil
+				},
+				nil,
+			),
+			river.NewPeriodicJob(
+				river.PeriodicInterval(time.Hour),
+				func() (river.JobArgs, *river.InsertOpts) {
+					return updates.CleanupArgs{}, nil
+				},
+				nil,
+			),
+			// Running on start also reconciles polls that expired while the
+			// worker was down
+			river.NewPeriodicJob(
+				river.PeriodicInterval(5*time.Minute),
+				func() (river.JobArgs, *river.InsertOpts) {
+					return polls.ReconcileArgs{}, nil
+				},
+				&river.PeriodicJobOpts{RunOnStart: true},
+			),
+		},
 	})
 
 	if err != nil {
 		log.Fatal("Failed to create river client")
 	}
 
+	var riverState health.State
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Ready(map[string]health.Check{
+		"river": riverState.Check,
+	}))
+	mux.HandleFunc("GET /readyz", health.Ready(map[string]health.Check{
+		"postgres": dbPool.Ping,
+		"river":    riverState.Check,
+	}))
+	srv := &http.Server{Addr: cfg.HTTPAddr, Handler: mux}
+	go func() {
+		log.Printf("Worker health listening on %s", cfg.HTTPAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("http server error: %v", err)
+		}
+	}()
+
 	if err := riverClient.Start(ctx); err != nil {
 		log.Fatal("Failed to start river client")
 	}
+	riverState.Set(health.Running)
 
 	fmt.Println("Successfully started worker")
 
@@ -83,6 +153,7 @@ func main() {
 	go func() {
 		<-sigintOrTerm
 		fmt.Printf("Received SIGINT/SIGTERM; initiating soft stop (try to wait for jobs to finish)\n")
+		riverState.Set(health.Stopping)
 
 		softStopCtx, softStopCtxCancel := context.WithTimeout(ctx, 10*time.Second)
 		defer softStopCtxCancel()
@@ -124,4 +195,9 @@ func main() {
 	}()
 
 	<-riverClient.Stopped()
+	riverState.Set(health.Stopped)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,76 @@
+package chats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+)
+
+const (
+	// DefaultLineupStrategy is used for chats that never picked a strategy
+	DefaultLineupStrategy = "uniform"
+	// DefaultTimezone is used for chats that never picked a timezone
+	DefaultTimezone = "Europe/Moscow"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+func (s *Repository) GetLineupStrategy(ctx context.Context, chatID int64) (string, error) {
+	var strategy string
+	err := s.DB.QueryRow(ctx, `SELECT lineup_strategy FROM chat_settings WHERE chat_id=$1`, chatID).Scan(&strategy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultLineupStrategy, nil
+	}
+	return strategy, err
+}
+
+func (s *Repository) SetLineupStrategy(ctx context.Context, chatID int64, strategy string) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, lineup_strategy, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET lineup_strategy=EXCLUDED.lineup_strategy, updated_at=NOW()`, chatID, strategy)
+	return err
+}
+
+// GetTimezone returns the location used to display times in the chat.
+func (s *Repository) GetTimezone(ctx context.Context, chatID int64) (*time.Location, error) {
+	name := DefaultTimezone
+	err := s.DB.QueryRow(ctx, `SELECT timezone FROM chat_settings WHERE chat_id=$1`, chatID).Scan(&name)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return time.LoadLocation(name)
+}
+
+func (s *Repository) SetTimezone(ctx context.Context, chatID int64, loc *time.Location) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, timezone, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET timezone=EXCLUDED.timezone, updated_at=NOW()`, chatID, loc.String())
+	return err
+}
+
+// GetLanguage returns the language the bot speaks in the chat.
+func (s *Repository) GetLanguage(ctx context.Context, chatID int64) (i18n.Lang, error) {
+	var code string
+	err := s.DB.QueryRow(ctx, `SELECT language FROM chat_settings WHERE chat_id=$1`, chatID).Scan(&code)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return i18n.Default, err
+	}
+	if lang, ok := i18n.Parse(code); ok {
+		return lang, nil
+	}
+	return i18n.Default, nil
+}
+
+func (s *Repository) SetLanguage(ctx context.Context, chatID int64, lang i18n.Lang) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, language, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET language=EXCLUDED.language, updated_at=NOW()`, chatID, string(lang))
+	return err
+}
@@ -0,0 +1,148 @@
+package chats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Settings are the per-chat preferences used when creating and finishing polls.
+type Settings struct {
+	ChatID          int64
+	LineupStrategy  string
+	Timezone        string
+	Topics          []string
+	DurationPresets []time.Duration
+	DefaultDuration time.Duration
+	MinDuration     time.Duration
+	MaxDuration     time.Duration
+	// OptionComing and OptionNotComing are the poll answers; empty ones are
+	// "Иду" and "Не иду" in the chat's language
+	OptionComing    string
+	OptionNotComing string
+	// PollCreatorRole is the lowest role allowed to create polls
+	PollCreatorRole string
+}
+
+// DefaultSettings returns the settings of a chat that never changed anything.
+func DefaultSettings(chatID int64) *Settings {
+	return &Settings{
+		ChatID:         chatID,
+		LineupStrategy: DefaultLineupStrategy,
+		Timezone:       DefaultTimezone,
+		Topics:         []string{"Анализ данных", "Информационная безопасность", "Промпт инжениринг", "Интерфейсы", "Сбер"},
+		DurationPresets: []time.Duration{
+			15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 4 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+		},
+		DefaultDuration: 30 * time.Minute,
+		MinDuration:     time.Minute,
+		MaxDuration:     7 * 24 * time.Hour,
+		PollCreatorRole: "member",
+	}
+}
+
+// Location returns the chat timezone.
+func (s *Settings) Location() (*time.Location, error) {
+	return time.LoadLocation(s.Timezone)
+}
+
+// DurationAllowed reports whether a poll may run for d in this chat.
+func (s *Settings) DurationAllowed(d time.Duration) bool {
+	return d >= s.MinDuration && d <= s.MaxDuration
+}
+
+// GetSettings returns the chat settings, filling in defaults for anything the
+// chat did not configure.
+func (s *Repository) GetSettings(ctx context.Context, chatID int64) (*Settings, error) {
+	res := DefaultSettings(chatID)
+	var topics []string
+	var presets []int32
+	var defaultSeconds *int32
+	var minSeconds, maxSeconds int32
+	err := s.DB.QueryRow(ctx, `SELECT lineup_strategy, timezone, topics, duration_presets, default_duration_seconds,
+		min_duration_seconds, max_duration_seconds, option_coming, option_not_coming, poll_creator_role
+	FROM chat_settings WHERE chat_id=$1`, chatID).
+		Scan(&res.LineupStrategy, &res.Timezone, &topics, &presets, &defaultSeconds,
+			&minSeconds, &maxSeconds, &res.OptionComing, &res.OptionNotComing, &res.PollCreatorRole)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if topics != nil {
+		res.Topics = topics
+	}
+	if presets != nil {
+		res.DurationPresets = make([]time.Duration, len(presets))
+		for i, p := range presets {
+			res.DurationPresets[i] = time.Duration(p) * time.Second
+		}
+	}
+	if defaultSeconds != nil {
+		res.DefaultDuration = time.Duration(*defaultSeconds) * time.Second
+	}
+	res.MinDuration = time.Duration(minSeconds) * time.Second
+	res.MaxDuration = time.Duration(maxSeconds) * time.Second
+	return res, nil
+}
+
+func (s *Repository) SetTopics(ctx context.Context, chatID int64, topics []string) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, topics, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET topics=EXCLUDED.topics, updated_at=NOW()`, chatID, topics)
+	return err
+}
+
+// SetDurations stores the duration buttons of the poll wizard and the
+// duration used when none is given.
+func (s *Repository) SetDurations(ctx context.Context, chatID int64, presets []time.Duration, defaultDuration time.Duration) error {
+	seconds := make([]int32, len(presets))
+	for i, p := range presets {
+		seconds[i] = int32(p / time.Second)
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, duration_presets, default_duration_seconds, updated_at) VALUES ($1,$2,$3,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET duration_presets=EXCLUDED.duration_presets,
+		default_duration_seconds=EXCLUDED.default_duration_seconds, updated_at=NOW()`,
+		chatID, seconds, int32(defaultDuration/time.Second))
+	return err
+}
+
+func (s *Repository) SetDurationLimits(ctx context.Context, chatID int64, minDuration, maxDuration time.Duration) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, min_duration_seconds, max_duration_seconds, updated_at) VALUES ($1,$2,$3,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET min_duration_seconds=EXCLUDED.min_duration_seconds,
+		max_duration_seconds=EXCLUDED.max_duration_seconds, updated_at=NOW()`,
+		chatID, int32(minDuration/time.Second), int32(maxDuration/time.Second))
+	return err
+}
+
+func (s *Repository) SetOptionLabels(ctx context.Context, chatID int64, coming, notComing string) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, option_coming, option_not_coming, updated_at) VALUES ($1,$2,$3,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET option_coming=EXCLUDED.option_coming,
+		option_not_coming=EXCLUDED.option_not_coming, updated_at=NOW()`, chatID, coming, notComing)
+	return err
+}
+
+func (s *Repository) SetPollCreatorRole(ctx context.Context, chatID int64, role string) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, poll_creator_role, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET poll_creator_role=EXCLUDED.poll_creator_role, updated_at=NOW()`, chatID, role)
+	return err
+}
+
+// GetLineupTemplate returns the queue line template of the chat results
+// message; empty means the default layout.
+func (s *Repository) GetLineupTemplate(ctx context.Context, chatID int64) (string, error) {
+	var text string
+	err := s.DB.QueryRow(ctx, `SELECT lineup_template FROM chat_settings WHERE chat_id=$1`, chatID).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return text, err
+}
+
+func (s *Repository) SetLineupTemplate(ctx context.Context, chatID int64, text string) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_settings (chat_id, lineup_template, updated_at) VALUES ($1,$2,NOW())
+	ON CONFLICT (chat_id) DO UPDATE SET lineup_template=EXCLUDED.lineup_template, updated_at=NOW()`, chatID, text)
+	return err
+}
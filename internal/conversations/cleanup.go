@@ -0,0 +1,8 @@
+package conversations
+
+// CleanupArgs defines the arguments for the periodic job that removes
+// expired conversation states.
+type CleanupArgs struct{}
+
+// Kind implements river.JobArgs to identify this job type.
+func (CleanupArgs) Kind() string { return "cleanup_conversation_states" }
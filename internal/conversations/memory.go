@@ -0,0 +1,74 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps conversation states in process memory. It is meant for
+// tests and single instance setups without a database.
+type MemoryStore struct {
+	TTL time.Duration
+
+	mu     sync.Mutex
+	states map[Key]memoryState
+}
+
+type memoryState struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{TTL: ttl, states: make(map[Key]memoryState)}
+}
+
+func (s *MemoryStore) Load(ctx context.Context, key Key, dst any) (bool, error) {
+	s.mu.Lock()
+	st, ok := s.states[key]
+	if ok && !time.Now().Before(st.expiresAt) {
+		delete(s.states, key)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(st.data, dst)
+}
+
+func (s *MemoryStore) Save(ctx context.Context, key Key, state any) error {
+	// Store a copy so callers cannot change a saved state by accident
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = memoryState{data: data, expiresAt: time.Now().Add(s.TTL)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// DeleteExpired removes expired states and returns how many were removed.
+func (s *MemoryStore) DeleteExpired(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	now := time.Now()
+	for key, st := range s.states {
+		if !now.Before(st.expiresAt) {
+			delete(s.states, key)
+			n++
+		}
+	}
+	return n, nil
+}
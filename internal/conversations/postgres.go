@@ -0,0 +1,61 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps conversation states in the conversation_states table,
+// so they survive restarts and are shared between service replicas.
+type PostgresStore struct {
+	DB  *pgxpool.Pool
+	TTL time.Duration
+}
+
+func NewPostgresStore(db *pgxpool.Pool, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{DB: db, TTL: ttl}
+}
+
+func (s *PostgresStore) Load(ctx context.Context, key Key, dst any) (bool, error) {
+	var data []byte
+	err := s.DB.QueryRow(ctx, `SELECT data FROM conversation_states
+	WHERE chat_id=$1 AND user_id=$2 AND flow=$3 AND expires_at > NOW()`, key.ChatID, key.UserID, key.Flow).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, dst)
+}
+
+func (s *PostgresStore) Save(ctx context.Context, key Key, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `INSERT INTO conversation_states (chat_id, user_id, flow, data, updated_at, expires_at)
+	VALUES ($1,$2,$3,$4,NOW(),$5)
+	ON CONFLICT (chat_id, user_id, flow) DO UPDATE SET data=EXCLUDED.data, updated_at=NOW(), expires_at=EXCLUDED.expires_at`,
+		key.ChatID, key.UserID, key.Flow, data, time.Now().UTC().Add(s.TTL))
+	return err
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, key Key) error {
+	_, err := s.DB.Exec(ctx, `DELETE FROM conversation_states WHERE chat_id=$1 AND user_id=$2 AND flow=$3`, key.ChatID, key.UserID, key.Flow)
+	return err
+}
+
+// DeleteExpired removes expired states and returns how many were removed.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM conversation_states WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,29 @@
+// Package conversations keeps the state of multi-step dialogs with users,
+// such as the poll creation wizard, between updates.
+package conversations
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTTL is how long an abandoned conversation is kept
+const DefaultTTL = 30 * time.Minute
+
+// Key identifies a conversation of one user in one chat
+type Key struct {
+	ChatID int64
+	UserID int64
+	// Flow tells conversations of the same user apart, e.g. "poll" or "settings"
+	Flow string
+}
+
+// Store persists conversation states. States are encoded as JSON and expire
+// after the TTL of the store; every Save extends it.
+type Store interface {
+	// Load decodes the state stored under key into dst and reports whether
+	// there was one.
+	Load(ctx context.Context, key Key, dst any) (bool, error)
+	Save(ctx context.Context, key Key, state any) error
+	Delete(ctx context.Context, key Key) error
+}
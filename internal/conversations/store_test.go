@@ -0,0 +1,152 @@
+package conversations
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type wizard struct {
+	Step  string
+	Topic string
+}
+
+// testStore checks the Store contract; every implementation should pass it.
+func testStore(t *testing.T, newStore func(ttl time.Duration) Store) {
+	ctx := context.Background()
+	key := Key{ChatID: -100, UserID: 7, Flow: "poll"}
+
+	t.Run("save and load", func(t *testing.T) {
+		s := newStore(time.Minute)
+		var got wizard
+		if ok, err := s.Load(ctx, key, &got); ok || err != nil {
+			t.Fatalf("Load before Save = %v, %v, want false, nil", ok, err)
+		}
+		want := wizard{Step: "duration", Topic: "<Разбор>"}
+		if err := s.Save(ctx, key, want); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if ok, err := s.Load(ctx, key, &got); !ok || err != nil || got != want {
+			t.Fatalf("Load = %v, %v, %+v, want true, nil, %+v", ok, err, got, want)
+		}
+		if err := s.Save(ctx, key, wizard{Step: "confirm"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		got = wizard{}
+		if s.Load(ctx, key, &got); got.Step != "confirm" || got.Topic != "" {
+			t.Errorf("Load after overwrite = %+v, want step confirm and no topic", got)
+		}
+	})
+
+	t.Run("keys are separate", func(t *testing.T) {
+		s := newStore(time.Minute)
+		if err := s.Save(ctx, key, wizard{Step: "topic"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		others := []Key{
+			{ChatID: -101, UserID: 7, Flow: "poll"},
+			{ChatID: -100, UserID: 8, Flow: "poll"},
+			{ChatID: -100, UserID: 7, Flow: "settings"},
+		}
+		for _, other := range others {
+			var got wizard
+			if ok, err := s.Load(ctx, other, &got); ok || err != nil {
+				t.Errorf("Load(%+v) = %v, %v, want false, nil", other, ok, err)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := newStore(time.Minute)
+		if err := s.Save(ctx, key, wizard{Step: "topic"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		var got wizard
+		if ok, err := s.Load(ctx, key, &got); ok || err != nil {
+			t.Errorf("Load after Delete = %v, %v, want false, nil", ok, err)
+		}
+		// Deleting a missing state is not an error
+		if err := s.Delete(ctx, key); err != nil {
+			t.Errorf("second Delete: %v", err)
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		const ttl = 100 * time.Millisecond
+		s := newStore(ttl)
+		if err := s.Save(ctx, key, wizard{Step: "topic"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		// Every Save extends the TTL
+		time.Sleep(ttl * 3 / 5)
+		if err := s.Save(ctx, key, wizard{Step: "duration"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		time.Sleep(ttl * 3 / 5)
+		var got wizard
+		if ok, _ := s.Load(ctx, key, &got); !ok {
+			t.Fatal("state expired although it was saved again within the TTL")
+		}
+		time.Sleep(ttl)
+		if ok, err := s.Load(ctx, key, &got); ok || err != nil {
+			t.Errorf("Load after TTL = %v, %v, want false, nil", ok, err)
+		}
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		s := newStore(time.Minute)
+		var wg sync.WaitGroup
+		for user := range 16 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				k := Key{ChatID: -100, UserID: int64(user), Flow: "poll"}
+				for i := range 50 {
+					want := wizard{Step: fmt.Sprint(i)}
+					if err := s.Save(ctx, k, want); err != nil {
+						t.Errorf("Save: %v", err)
+						return
+					}
+					// Every user also touches the shared key
+					s.Save(ctx, key, want)
+					s.Load(ctx, key, &wizard{})
+					var got wizard
+					if ok, err := s.Load(ctx, k, &got); !ok || err != nil || got != want {
+						t.Errorf("user %d: Load = %v, %v, %+v, want %+v", user, ok, err, got, want)
+						return
+					}
+				}
+				s.Delete(ctx, k)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(ttl time.Duration) Store { return NewMemoryStore(ttl) })
+}
+
+func TestMemoryStoreDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(50 * time.Millisecond)
+	s.Save(ctx, Key{ChatID: 1, UserID: 1, Flow: "poll"}, wizard{})
+	s.Save(ctx, Key{ChatID: 1, UserID: 2, Flow: "poll"}, wizard{})
+	time.Sleep(60 * time.Millisecond)
+	fresh := Key{ChatID: 1, UserID: 3, Flow: "poll"}
+	s.Save(ctx, fresh, wizard{Step: "topic"})
+
+	n, err := s.DeleteExpired(ctx)
+	if err != nil || n != 2 {
+		t.Errorf("DeleteExpired = %d, %v, want 2, nil", n, err)
+	}
+	var got wizard
+	if ok, _ := s.Load(ctx, fresh, &got); !ok || got.Step != "topic" {
+		t.Errorf("fresh state was removed: %v, %+v", ok, got)
+	}
+}
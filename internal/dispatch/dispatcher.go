@@ -0,0 +1,137 @@
+// Package dispatch hands Telegram updates to a fixed pool of workers. Updates
+// of one chat always go to the same worker, so they are handled in order,
+// while different chats are handled in parallel.
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrClosed is returned by Dispatch after Shutdown was called.
+var ErrClosed = errors.New("dispatcher is shut down")
+
+// HandlerFunc handles a single update.
+type HandlerFunc func(ctx context.Context, update tgbotapi.Update)
+
+type Dispatcher struct {
+	handle HandlerFunc
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+
+	// mu guards closed; Dispatch holds it for reading so queues are never
+	// closed under a sender
+	mu     sync.RWMutex
+	closed bool
+
+	// ctx is given to handlers; it is cancelled only when draining times out
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New starts workers goroutines, each with a queue of queueSize updates.
+func New(workers, queueSize int, handle HandlerFunc) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, workers),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+// Dispatch queues update for its chat's worker. It blocks while that queue
+// is full, until ctx is done.
+func (d *Dispatcher) Dispatch(ctx context.Context, update tgbotapi.Update) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+	select {
+	case d.queues[d.shard(update)] <- update:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting updates and waits for the queued ones to be
+// handled. If ctx is done first, handlers are cancelled and ctx's error is
+// returned.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, q := range d.queues {
+			close(q)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work(queue <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		d.safeHandle(update)
+	}
+}
+
+// safeHandle keeps a panicking handler from taking the worker down with it.
+func (d *Dispatcher) safeHandle(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic handling update %d: %v", update.UpdateID, r)
+		}
+	}()
+	d.handle(d.ctx, update)
+}
+
+// shard picks the worker for update by hashing its ordering key.
+func (d *Dispatcher) shard(update tgbotapi.Update) int {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.FormatInt(orderingKey(update), 10)))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+// orderingKey is the chat of update, or its sender when it has no chat, e.g.
+// poll answers.
+func orderingKey(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	case update.PollAnswer != nil:
+		return update.PollAnswer.User.ID
+	}
+	return int64(update.UpdateID)
+}
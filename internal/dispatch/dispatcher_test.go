@@ -0,0 +1,170 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func message(updateID int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{UpdateID: updateID, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestDispatchKeepsChatOrder(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64][]int)
+	)
+	d := New(4, 8, func(ctx context.Context, update tgbotapi.Update) {
+		// Uneven handling times would reorder updates of a chat if they ran
+		// in parallel
+		time.Sleep(time.Duration(update.UpdateID%3) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.UpdateID)
+	})
+
+	const chats, perChat = 5, 40
+	for i := range perChat {
+		for chat := range chats {
+			if err := d.Dispatch(context.Background(), message(i*chats+chat, int64(-100-chat))); err != nil {
+				t.Fatalf("Dispatch: %v", err)
+			}
+		}
+	}
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	for chat := range chats {
+		got := seen[int64(-100-chat)]
+		if len(got) != perChat || !slices.IsSorted(got) {
+			t.Errorf("chat %d handled %v, want %d updates in order", chat, got, perChat)
+		}
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		mu      sync.Mutex
+		handled []int
+	)
+	d := New(1, 10, func(ctx context.Context, update tgbotapi.Update) {
+		<-release
+		mu.Lock()
+		handled = append(handled, update.UpdateID)
+		mu.Unlock()
+	})
+	for i := range 5 {
+		if err := d.Dispatch(context.Background(), message(i, 1)); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+
+	done := make(chan error)
+	go func() { done <- d.Shutdown(context.Background()) }()
+	// Shutdown must wait for the queued updates
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v before the queue was handled", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := d.Dispatch(context.Background(), message(5, 1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Dispatch after Shutdown = %v, want ErrClosed", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestShutdownTimeoutCancelsHandlers(t *testing.T) {
+	cancelled := make(chan struct{})
+	d := New(1, 1, func(ctx context.Context, update tgbotapi.Update) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	if err := d.Dispatch(context.Background(), message(1, 1)); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the running handler was not cancelled")
+	}
+}
+
+func TestDispatchBlocksOnFullQueue(t *testing.T) {
+	release := make(chan struct{})
+	d := New(1, 1, func(ctx context.Context, update tgbotapi.Update) { <-release })
+	defer func() {
+		close(release)
+		d.Shutdown(context.Background())
+	}()
+	// One update is being handled and one waits in the queue
+	d.Dispatch(context.Background(), message(1, 1))
+	d.Dispatch(context.Background(), message(2, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Dispatch(ctx, message(3, 1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Dispatch to a full queue = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPanickingHandlerKeepsWorker(t *testing.T) {
+	var handled []int
+	d := New(1, 4, func(ctx context.Context, update tgbotapi.Update) {
+		if update.UpdateID == 1 {
+			panic("boom")
+		}
+		handled = append(handled, update.UpdateID)
+	})
+	for i := range 3 {
+		d.Dispatch(context.Background(), message(i, 1))
+	}
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if want := []int{0, 2}; !slices.Equal(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestOrderingKey(t *testing.T) {
+	chat := &tgbotapi.Chat{ID: -100}
+	user := &tgbotapi.User{ID: 7}
+	tests := []struct {
+		name   string
+		update tgbotapi.Update
+		want   int64
+	}{
+		{"message", tgbotapi.Update{Message: &tgbotapi.Message{Chat: chat}}, -100},
+		{"button", tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{From: user, Message: &tgbotapi.Message{Chat: chat}}}, -100},
+		{"inline button", tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{From: user}}, 7},
+		{"poll answer", tgbotapi.Update{PollAnswer: &tgbotapi.PollAnswer{User: *user}}, 7},
+		{"other", tgbotapi.Update{UpdateID: 42}, 42},
+	}
+	for _, tt := range tests {
+		if got := orderingKey(tt.update); got != tt.want {
+			t.Errorf("%s: orderingKey = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,249 +2,355 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/lineup"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
 	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
 	"github.com/nikitkaralius/lineup/internal/voters"
 )
 
 // PollCreationState represents the current state of poll creation
 type PollCreationState struct {
-	Step      string // "topic", "duration", "confirm"
-	Topic     string
-	Duration  time.Duration
-	MessageID int // ID of the initial poll creation message to delete after topic input
+	Step      string        `json:"step"` // "topic", "duration", "confirm"
+	Topic     string        `json:"topic"`
+	Duration  time.Duration `json:"duration"`
+	MessageID int           `json:"message_id"` // ID of the initial poll creation message to delete after topic input
+	// MaxParticipants limits the main list; 0 means unlimited
+	MaxParticipants int `json:"max_participants"`
+	// SessionStart and SlotLength schedule the queue; zero means no schedule
+	SessionStart time.Time     `json:"session_start"`
+	SlotLength   time.Duration `json:"slot_length"`
 }
 
-// In-memory storage for poll creation states (in production, consider using Redis or database)
-var pollCreationStates = make(map[string]*PollCreationState)
+// pollFlow names the poll creation wizard in the conversation store
+const pollFlow = "poll"
 
-func HandleCallback(
-	ctx context.Context,
-	bot *tgbotapi.BotAPI,
-	pollsRepo *polls.Repository,
-	votersRepo *voters.Repository,
-	callback *tgbotapi.CallbackQuery,
-	botUsername string,
-	pollsService polls.Service,
-) {
-	if callback == nil || callback.Data == "" {
-		return
+func loadPollState(ctx context.Context, states conversations.Store, chatID int64, userID int64) (*PollCreationState, bool) {
+	var state PollCreationState
+	ok, err := states.Load(ctx, conversations.Key{ChatID: chatID, UserID: userID, Flow: pollFlow}, &state)
+	if err != nil {
+		log.Printf("Error loading poll creation state: %v", err)
+		return nil, false
 	}
+	return &state, ok
+}
 
-	data := callback.Data
-	chatID := callback.Message.Chat.ID
-	messageID := callback.Message.MessageID
-	userID := callback.From.ID
-
-	// Answer callback to remove loading state
-	answerCallback := tgbotapi.NewCallback(callback.ID, "")
-	bot.Request(answerCallback)
-
-	switch {
-	case data == "create_poll":
-		handleStartPollCreation(ctx, bot, chatID, messageID, userID)
-	case strings.HasPrefix(data, "poll_topic:"):
-		handleTopicSelection(ctx, bot, chatID, messageID, userID, data)
-	case data == "poll_topic_custom":
-		handleCustomTopicInput(ctx, bot, chatID, messageID, userID)
-	case strings.HasPrefix(data, "poll_duration:"):
-		handleDurationSelection(ctx, bot, pollsRepo, chatID, messageID, userID, data, pollsService)
-	case data == "poll_duration_custom":
-		handleCustomDurationInput(ctx, bot, chatID, messageID, userID)
-	case data == "poll_confirm":
-		handleConfirmPoll(ctx, bot, pollsRepo, chatID, messageID, userID, pollsService)
-	case data == "poll_back":
-		handleBackToPollCreation(ctx, bot, chatID, messageID, userID)
-	case data == "poll_back_to_duration":
-		handleBackToDurationSelection(ctx, bot, chatID, messageID, userID)
-	case data == "poll_back_to_topic":
-		handleBackToTopicSelection(ctx, bot, chatID, messageID, userID)
-	case data == "poll_cancel":
-		handleCancelPollCreation(ctx, bot, chatID, messageID, userID)
-	case strings.HasPrefix(data, "queue_exit:"):
-		handleQueueExit(ctx, bot, pollsRepo, votersRepo, callback, data)
-	case strings.HasPrefix(data, "queue_join:"):
-		handleQueueJoin(ctx, bot, pollsRepo, votersRepo, callback, data)
-	default:
-		log.Printf("Unknown callback data: %s", data)
-	}
-}
-
-func handleStartPollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	pollCreationStates[stateKey] = &PollCreationState{Step: "topic"}
-
-	text := "📝 *Создание опроса*\n\nВведите тему опроса:"
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-		),
-	)
+func savePollState(ctx context.Context, states conversations.Store, chatID int64, userID int64, state *PollCreationState) {
+	if err := states.Save(ctx, conversations.Key{ChatID: chatID, UserID: userID, Flow: pollFlow}, state); err != nil {
+		log.Printf("Error saving poll creation state: %v", err)
+	}
+}
 
-	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-	edit.ParseMode = "Markdown"
-	edit.ReplyMarkup = &keyboard
-	bot.Send(edit)
+func deletePollState(ctx context.Context, states conversations.Store, chatID int64, userID int64) {
+	if err := states.Delete(ctx, conversations.Key{ChatID: chatID, UserID: userID, Flow: pollFlow}); err != nil {
+		log.Printf("Error deleting poll creation state: %v", err)
+	}
 }
 
-func handleTopicSelection(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64, data string) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleTopicSelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64, data string) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "topic" {
 		return
 	}
 
-	// Extract topic from callback data
+	// Extract topic index from callback data
 	parts := strings.Split(data, ":")
 	if len(parts) != 2 {
 		return
 	}
+	topics := chatSettings(ctx, chatsRepo, chatID).Topics
+	i, err := strconv.Atoi(parts[1])
+	if err != nil || i < 0 || i >= len(topics) {
+		log.Printf("Invalid topic index: %s", parts[1])
+		return
+	}
 
-	topic := parts[1]
+	topic := topics[i]
 	state.Topic = topic
 	state.Step = "duration"
+	savePollState(ctx, states, chatID, userID, state)
 
 	// Update the message to show selected topic and remove cancel button
-	updatedText := fmt.Sprintf("📝 *Создание опроса*\n\n✅ **Тема:** %s", topic)
+	updatedText := p.HTML("📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s", topic)
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, updatedText)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 	bot.Send(edit)
 
 	// Show duration selection
-	showDurationSelection(ctx, bot, chatID, messageID, userID, topic)
+	showDurationSelection(ctx, bot, states, chatsRepo, chatID, messageID, userID, topic)
 }
 
-func handleDurationSelection(ctx context.Context, bot *tgbotapi.BotAPI, pollsRepo *polls.Repository, chatID int64, messageID int, userID int64, data string, pollsService polls.Service) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+// handleDurationSelection answers the button itself, so a refused duration
+// shows as an alert to the presser rather than a message to the chat.
+func handleDurationSelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery) {
+	p := i18n.ForUser(ctx)
+	chatID, messageID, userID := callback.Message.Chat.ID, callback.Message.MessageID, callback.From.ID
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "duration" {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 		return
 	}
 
 	// Extract duration from callback data
-	parts := strings.Split(data, ":")
+	parts := strings.Split(callback.Data, ":")
 	if len(parts) != 2 {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 		return
 	}
 
 	durationStr := parts[1]
 	duration, err := time.ParseDuration(durationStr)
-	if err != nil {
-		log.Printf("Invalid duration format: %s", durationStr)
+	// The presets are within the limits, but the data of a button can be forged
+	settings := chatSettings(ctx, chatsRepo, chatID)
+	if err != nil || !settings.DurationAllowed(duration) {
+		log.Printf("Invalid duration: %s", durationStr)
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, p.T("❌ Длительность должна быть от %s до %s",
+			p.Duration(settings.MinDuration), p.Duration(settings.MaxDuration))))
 		return
 	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 
 	state.Duration = duration
 	state.Step = "confirm"
+	savePollState(ctx, states, chatID, userID, state)
 
 	// Update the message to show selected topic and remove cancel button
-	formattedDur := formatDuration(duration)
-	updatedText := fmt.Sprintf("📝 *Создание опроса*\n\n✅ **Тема:** %s\n⏰ **Длительность:** %s\n", state.Topic, formattedDur)
+	formattedDur := p.Duration(duration)
+	updatedText := p.HTML("📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s\n⏰ <b>Длительность:</b> %s\n", state.Topic, formattedDur)
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, updatedText)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 	bot.Send(edit)
 
 	// Show confirmation
-	text := fmt.Sprintf("✅ *Подтверждение опроса*\n\n📋 **Тема:** %s\n⏰ **Длительность:** %s\n\nВсё правильно?",
-		state.Topic, formattedDur)
+	text, keyboard := pollConfirmation(p, state, chatLocation(ctx, chatsRepo, chatID))
+	edit = tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+// pollConfirmation builds the confirmation step, where the participant limit
+// can also be chosen.
+func pollConfirmation(p *i18n.Printer, state *PollCreationState, loc *time.Location) (string, tgbotapi.InlineKeyboardMarkup) {
+	schedule := p.T("не задано")
+	if !state.SessionStart.IsZero() {
+		schedule = p.T("%s, по %s на человека", pollcreate.FormatTime(state.SessionStart, loc), p.Duration(state.SlotLength))
+	}
+	text := p.HTML("✅ <b>Подтверждение опроса</b>\n\n📋 <b>Тема:</b> %s\n⏰ <b>Длительность:</b> %s\n👥 <b>Мест:</b> %s\n🗓 <b>Начало занятия:</b> %s\n\nВсё правильно?",
+		state.Topic, p.Duration(state.Duration), formatCapacity(p, state.MaxParticipants), schedule)
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Создать", "poll_confirm"),
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "poll_back"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("♾ Без лимита"), "poll_capacity:0"),
+			tgbotapi.NewInlineKeyboardButtonData("10", "poll_capacity:10"),
+			tgbotapi.NewInlineKeyboardButtonData("20", "poll_capacity:20"),
+			tgbotapi.NewInlineKeyboardButtonData("30", "poll_capacity:30"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✏️ Свое число мест"), "poll_capacity_custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🗓 Время начала и слоты"), "poll_schedule_custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✅ Создать"), "poll_confirm"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "poll_back"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
 		),
 	)
+	return text, keyboard
+}
 
-	edit = tgbotapi.NewEditMessageText(chatID, messageID, text)
-	edit.ParseMode = "Markdown"
+func handleCapacitySelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64, data string) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists || state.Step != "confirm" {
+		return
+	}
+
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 {
+		return
+	}
+	capacity, err := strconv.Atoi(parts[1])
+	if err != nil || capacity < 0 {
+		log.Printf("Invalid capacity: %s", parts[1])
+		return
+	}
+	if capacity == state.MaxParticipants {
+		return
+	}
+	state.MaxParticipants = capacity
+	savePollState(ctx, states, chatID, userID, state)
+
+	text, keyboard := pollConfirmation(p, state, chatLocation(ctx, chatsRepo, chatID))
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &keyboard
 	bot.Send(edit)
 }
 
-func handleConfirmPoll(ctx context.Context, bot *tgbotapi.BotAPI, pollsRepo *polls.Repository, chatID int64, messageID int, userID int64, pollsService polls.Service) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleCustomCapacityInput(ctx context.Context, bot telegram.Client, states conversations.Store, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "confirm" {
 		return
 	}
 
-	// Create enhanced poll question with duration and end time
-	endTime := time.Now().UTC().Add(state.Duration)
-	pollQuestion := fmt.Sprintf("📋 Тема: %s\n⏰ Длительность: %s\n🕐 Завершится: %s",
-		state.Topic,
-		formatDuration(state.Duration),
-		formatTimeInMSK(endTime))
+	state.Step = "capacity_custom"
+	savePollState(ctx, states, chatID, userID, state)
 
-	// Create poll with Russian options
-	pollCfg := tgbotapi.NewPoll(chatID, pollQuestion, []string{"Иду", "Не иду"}...)
-	pollCfg.IsAnonymous = false
-	pollCfg.AllowsMultipleAnswers = false
-	sent, err := bot.Send(pollCfg)
-	if err != nil {
-		log.Printf("send poll error: %v", err)
-		// Show error message
-		text := "❌ Ошибка при создании опроса. Попробуйте позже."
-		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-		edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
-		bot.Send(edit)
-		delete(pollCreationStates, stateKey)
+	text := p.HTML("✏️ <b>Ввод числа мест</b>\n\n📋 <b>Тема:</b> %s\n\nВведите, сколько человек попадёт в основной список. Остальные окажутся в листе ожидания.\n\nПример: <code>12</code>, <code>25</code>", state.Topic)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "poll_back_to_confirm"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
+		),
+	)
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+func handleCustomScheduleInput(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists || state.Step != "confirm" {
 		return
 	}
-	if sent.Poll == nil {
-		log.Printf("poll send returned no poll")
+
+	state.Step = "schedule_custom"
+	savePollState(ctx, states, chatID, userID, state)
+
+	loc := chatLocation(ctx, chatsRepo, chatID)
+	text := p.HTML("🗓 <b>Время начала и слоты</b>\n\n📋 <b>Тема:</b> %s\n\nВведите время начала занятия и длительность выступления одного человека. Время указывается в часовом поясе чата (%s).\n\nПример: <code>18:30 10m</code>, <code>25.12 09:00 15m</code>", state.Topic, loc.String())
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🗑 Без расписания"), "poll_schedule_clear"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "poll_back_to_confirm"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
+		),
+	)
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+func handleClearSchedule(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists || state.Step != "schedule_custom" {
 		return
 	}
 
-	// Store poll in database
-	p := &polls.TelegramPollDTO{
-		PollID:          sent.Poll.ID,
-		ChatID:          chatID,
-		MessageID:       sent.MessageID,
-		Topic:           state.Topic,
-		CreatorID:       userID,
-		CreatorUsername: "", // Will be filled from callback.From if available
-		CreatorName:     "", // Will be filled from callback.From if available
-		StartedAt:       time.Now().UTC(),
-		Duration:        state.Duration,
-		EndsAt:          time.Now().UTC().Add(state.Duration),
+	state.SessionStart = time.Time{}
+	state.SlotLength = 0
+	state.Step = "confirm"
+	savePollState(ctx, states, chatID, userID, state)
+
+	text, keyboard := pollConfirmation(p, state, chatLocation(ctx, chatsRepo, chatID))
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+func handleBackToConfirm(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists || (state.Step != "capacity_custom" && state.Step != "schedule_custom") {
+		return
 	}
 
-	if err := pollsRepo.InsertPoll(ctx, p); err != nil {
-		log.Printf("insert poll error: %v", err)
+	state.Step = "confirm"
+	savePollState(ctx, states, chatID, userID, state)
+	text, keyboard := pollConfirmation(p, state, chatLocation(ctx, chatsRepo, chatID))
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+func handleConfirmPoll(ctx context.Context, bot telegram.Client, states conversations.Store, pollsRepo *polls.Repository, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64, pollsService polls.Service) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists || state.Step != "confirm" {
+		return
 	}
 
-	// Schedule poll completion job
-	if pollsService != nil {
-		args := polls.FinishPollArgs{PollID: p.PollID, ChatID: p.ChatID, MessageID: p.MessageID, Topic: p.Topic}
-		if err := pollsService.SchedulePollFinish(ctx, args, p.EndsAt); err != nil {
-			log.Printf("enqueue finish poll error: %v", err)
-		}
+	settings := chatSettings(ctx, chatsRepo, chatID)
+	_, err := pollcreate.Create(ctx, bot, pollsRepo, pollsService, pollcreate.Request{
+		ChatID:          chatID,
+		Topic:           state.Topic,
+		Duration:        state.Duration,
+		MaxParticipants: state.MaxParticipants,
+		SessionStart:    state.SessionStart,
+		SlotLength:      state.SlotLength,
+		CreatorID:       userID,
+		OptionComing:    settings.OptionComing,
+		OptionNotComing: settings.OptionNotComing,
+		Location:        chatLocation(ctx, chatsRepo, chatID),
+		Printer:         i18n.ForChat(ctx),
+	})
+	if err != nil {
+		log.Printf("create poll error: %v", err)
+		// Keep the state so the same poll can be retried
+		text := p.T("❌ Ошибка при создании опроса, опрос не был создан. Попробуйте ещё раз.")
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(p.T("🔁 Повторить"), "poll_confirm"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
+			),
+		)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		edit.ReplyMarkup = &keyboard
+		bot.Send(edit)
+		return
 	}
 
 	// Update the creation message to show completion
-	completionText := "✅ *Опрос успешно создан!*"
+	completionText := p.HTML("✅ <b>Опрос успешно создан!</b>")
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, completionText)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 	bot.Send(edit)
 
 	// Clean up state
-	delete(pollCreationStates, stateKey)
+	deletePollState(ctx, states, chatID, userID)
 }
 
-func handleBackToPollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleBackToPollCreation(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists {
 		return
 	}
@@ -252,309 +358,470 @@ func handleBackToPollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chatID
 	if state.Step == "confirm" {
 		// Go back to duration selection
 		state.Step = "duration"
-		showDurationSelection(ctx, bot, chatID, messageID, userID, state.Topic)
+		savePollState(ctx, states, chatID, userID, state)
+		showDurationSelection(ctx, bot, states, chatsRepo, chatID, messageID, userID, state.Topic)
 	}
 }
 
-func handleBackToTopicSelection(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleBackToTopicSelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "topic_custom" {
 		return
 	}
 
 	// Go back to topic selection
 	state.Step = "topic"
-	showTopicSelection(ctx, bot, chatID, messageID, userID)
+	savePollState(ctx, states, chatID, userID, state)
+	showTopicSelection(ctx, bot, chatsRepo, chatID, messageID, userID)
 }
 
-func handleBackToDurationSelection(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleBackToDurationSelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "duration_custom" {
 		return
 	}
 
 	// Go back to duration selection
 	state.Step = "duration"
-	showDurationSelection(ctx, bot, chatID, messageID, userID, state.Topic)
+	savePollState(ctx, states, chatID, userID, state)
+	showDurationSelection(ctx, bot, states, chatsRepo, chatID, messageID, userID, state.Topic)
 }
 
-func handleCancelPollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	delete(pollCreationStates, stateKey)
+func handleCancelPollCreation(ctx context.Context, bot telegram.Client, states conversations.Store, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	deletePollState(ctx, states, chatID, userID)
 
-	text := "❌ Создание опроса отменено."
+	text := p.T("❌ Создание опроса отменено.")
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
 	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 	bot.Send(edit)
 }
 
-func handleCustomTopicInput(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleCustomTopicInput(ctx context.Context, bot telegram.Client, states conversations.Store, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "topic" {
 		return
 	}
 
 	// Update state to custom topic input
 	state.Step = "topic_custom"
+	savePollState(ctx, states, chatID, userID, state)
 
 	// Show custom topic input prompt
-	text := "✏️ *Ввод темы*\n\nВведите тему опроса:\n\nПример: `Базы данных`, `Стрельба из лука`, `Battlefield 6`"
+	text := p.HTML("✏️ <b>Ввод темы</b>\n\nВведите тему опроса:\n\nПример: <code>Базы данных</code>, <code>Стрельба из лука</code>, <code>Battlefield 6</code>")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "poll_back_to_topic"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "poll_back_to_topic"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
 		),
 	)
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &keyboard
 	bot.Send(edit)
 }
 
-func handleCustomDurationInput(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-	state, exists := pollCreationStates[stateKey]
+func handleCustomDurationInput(ctx context.Context, bot telegram.Client, states conversations.Store, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	state, exists := loadPollState(ctx, states, chatID, userID)
 	if !exists || state.Step != "duration" {
 		return
 	}
 
 	// Update state to custom duration input
 	state.Step = "duration_custom"
+	savePollState(ctx, states, chatID, userID, state)
 
 	// Show custom duration input prompt
-	text := fmt.Sprintf("✏️ *Ввод длительности*\n\n📋 **Тема:** %s\n\nВведите длительность в формате:\n• `30m` - минуты\n• `2h` - часы\n• `1h30m` - комбинированный формат\n• `24h` - сутки\n\nПример: `45m`, `2h30m`, `6h`", state.Topic)
+	text := p.HTML("✏️ <b>Ввод длительности</b>\n\n📋 <b>Тема:</b> %s\n\nВведите длительность в формате:\n• <code>30m</code> - минуты\n• <code>2h</code> - часы\n• <code>1h30m</code> - комбинированный формат\n• <code>24h</code> - сутки\n\nПример: <code>45m</code>, <code>2h30m</code>, <code>6h</code>", state.Topic)
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "poll_back_to_duration"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "poll_back_to_duration"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
 		),
 	)
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &keyboard
 	bot.Send(edit)
 }
 
-func showTopicSelection(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
-	text := "📝 *Создание опроса*\n\nВыберите тему опроса или введите свою:"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Анализ данных", "poll_topic:Анализ данных"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔒 Информационная безопасность", "poll_topic:Информационная безопасность"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🤖 Промпт инжениринг", "poll_topic:Промпт инжениринг"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎨 Интерфейсы", "poll_topic:Интерфейсы"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏛️ Сбер", "poll_topic:Сбер"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✏️ Свое значение", "poll_topic_custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-		),
-	)
+func showTopicSelection(ctx context.Context, bot telegram.Client, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64) {
+	p := i18n.ForUser(ctx)
+	text := p.HTML("📝 <b>Создание опроса</b>\n\nВыберите тему опроса или введите свою:")
+	keyboard := topicKeyboard(p, chatSettings(ctx, chatsRepo, chatID))
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-	edit.ParseMode = "Markdown"
+	edit.ParseMode = render.ParseMode
 	edit.ReplyMarkup = &keyboard
 	bot.Send(edit)
 }
 
-func showDurationSelection(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64, topic string) {
-	text := fmt.Sprintf("⏰ *Выбор длительности опроса*\n\n📋 **Тема:** %s\n\nВыберите длительность или введите свою:", topic)
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⏱ 15 минут", "poll_duration:15m"),
-			tgbotapi.NewInlineKeyboardButtonData("⏰ 30 минут", "poll_duration:30m"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🕐 1 час", "poll_duration:1h"),
-			tgbotapi.NewInlineKeyboardButtonData("🕕 2 часа", "poll_duration:2h"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🕘 4 часа", "poll_duration:4h"),
-			tgbotapi.NewInlineKeyboardButtonData("🌅 12 часов", "poll_duration:12h"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 1 день", "poll_duration:24h"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✏️ Свое значение", "poll_duration_custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-		),
-	)
+func showDurationSelection(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, messageID int, userID int64, topic string) {
+	p := i18n.ForUser(ctx)
+	text := p.HTML("⏰ <b>Выбор длительности опроса</b>\n\n📋 <b>Тема:</b> %s\n\nВыберите длительность или введите свою:", topic)
+	keyboard := durationKeyboard(p, chatSettings(ctx, chatsRepo, chatID))
 
 	if messageID == 0 {
 		// Create new message (for custom topic input flow)
 		msg := tgbotapi.NewMessage(chatID, text)
-		msg.ParseMode = "Markdown"
+		msg.ParseMode = render.ParseMode
 		msg.ReplyMarkup = keyboard
 		sent, _ := bot.Send(msg)
-		stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-		pollCreationStates[stateKey].MessageID = sent.MessageID
+		if state, ok := loadPollState(ctx, states, chatID, userID); ok {
+			state.MessageID = sent.MessageID
+			savePollState(ctx, states, chatID, userID, state)
+		}
 	} else {
 		// Edit existing message (for callback flows)
 		edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
-		edit.ParseMode = "Markdown"
+		edit.ParseMode = render.ParseMode
 		edit.ReplyMarkup = &keyboard
 		bot.Send(edit)
 	}
 }
 
-func handleQueueExit(ctx context.Context, bot *tgbotapi.BotAPI, pollsRepo *polls.Repository, votersRepo *voters.Repository, callback *tgbotapi.CallbackQuery, data string) {
-	// Extract poll_id from callback data
-	parts := strings.Split(data, ":")
-	if len(parts) != 2 {
+// topicIcons decorate the default topics in the wizard
+var topicIcons = map[string]string{
+	"Анализ данных":               "📊",
+	"Информационная безопасность": "🔒",
+	"Промпт инжениринг":           "🤖",
+	"Интерфейсы":                  "🎨",
+	"Сбер":                        "🏛️",
+}
+
+// topicKeyboard lists the chat topics. Buttons carry the topic index, since
+// topics may not fit into the 64 bytes of callback data.
+func topicKeyboard(p *i18n.Printer, settings *chats.Settings) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, topic := range settings.Topics {
+		icon, ok := topicIcons[topic]
+		if !ok {
+			icon = "📋"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(icon+" "+topic, fmt.Sprintf("poll_topic:%d", i)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✏️ Свое значение"), "poll_topic_custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// durationKeyboard lists the chat duration presets two per row, marking the
+// default one.
+func durationKeyboard(p *i18n.Printer, settings *chats.Settings) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, d := range settings.DurationPresets {
+		label := "⏱ " + p.Duration(d)
+		if d == settings.DefaultDuration {
+			label = "⭐ " + p.Duration(d)
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "poll_duration:"+d.String()))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✏️ Свое значение"), "poll_duration_custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("❌ Отмена"), "poll_cancel"),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func handleQueueExit(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	pollID, page, ok := parseQueueData(data)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+	if !queueOpen(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Опрос не найден")))
 		return
 	}
-	pollID := parts[1]
 
 	// Remove user from queue by updating their vote to "not coming" (option 1)
 	err := votersRepo.UpsertVote(ctx, pollID, *callback.From, []int{1})
 	if err != nil {
 		log.Printf("Error removing user from queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
+		return
+	}
+	position, err := votersRepo.RemoveFromQueue(ctx, pollID, callback.From.ID)
+	if err != nil {
+		log.Printf("Error removing user from queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
 		return
 	}
 
 	// Update the results message
-	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, callback.Message, pollID)
+	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, chatsRepo, callback.Message.Chat.ID, callback.Message.MessageID, pollID, page)
+
+	// A freed place in the main list goes to the first waitlisted person
+	if position > 0 {
+		promoteFromWaitlist(ctx, bot, pollsRepo, votersRepo, callback.Message.Chat.ID, callback.Message.MessageID, pollID, position)
+	}
 
 	// Send confirmation
-	confirmText := "🚪 Вы вышли из очереди"
+	confirmText := p.T("🚪 Вы вышли из очереди")
 	answerCallback := tgbotapi.NewCallback(callback.ID, confirmText)
 	bot.Request(answerCallback)
 }
 
-func handleQueueJoin(ctx context.Context, bot *tgbotapi.BotAPI, pollsRepo *polls.Repository, votersRepo *voters.Repository, callback *tgbotapi.CallbackQuery, data string) {
-	// Extract poll_id from callback data
-	parts := strings.Split(data, ":")
-	if len(parts) != 2 {
+// promoteFromWaitlist notifies the person who moved from the waitlist into
+// the main list after someone at freedPosition left.
+func promoteFromWaitlist(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatID int64, messageID int, pollID string, freedPosition int) {
+	p := i18n.ForChat(ctx)
+	poll, err := pollsRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting poll: %v", err)
+		return
+	}
+	if poll.MaxParticipants == 0 || freedPosition > poll.MaxParticipants {
+		return
+	}
+	entries, err := votersRepo.GetQueue(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		return
+	}
+	for _, e := range entries {
+		if e.Position == poll.MaxParticipants {
+			msg := tgbotapi.NewMessage(chatID, lineup.Promotion(p, e.TelegramVoterDTO, e.Position))
+			msg.ParseMode = render.ParseMode
+			msg.ReplyToMessageID = messageID
+			bot.Send(msg)
+			return
+		}
+	}
+}
+
+func handleQueueJoin(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	pollID, page, ok := parseQueueData(data)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+	if !queueOpen(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Опрос не найден")))
 		return
 	}
-	pollID := parts[1]
 
 	// Add user to queue by updating their vote to "coming" (option 0)
 	err := votersRepo.UpsertVote(ctx, pollID, *callback.From, []int{0})
 	if err != nil {
 		log.Printf("Error adding user to queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
+		return
+	}
+	if err := votersRepo.AppendToQueue(ctx, pollID, callback.From.ID); err != nil {
+		log.Printf("Error adding user to queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
 		return
 	}
 
 	// Update the results message
-	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, callback.Message, pollID)
+	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, chatsRepo, callback.Message.Chat.ID, callback.Message.MessageID, pollID, page)
 
 	// Send confirmation
-	confirmText := "🙋 Вы присоединились к очереди"
+	confirmText := p.T("🙋 Вы присоединились к очереди")
 	answerCallback := tgbotapi.NewCallback(callback.ID, confirmText)
 	bot.Request(answerCallback)
 }
 
-func updateQueueMessage(ctx context.Context, bot *tgbotapi.BotAPI, pollsRepo *polls.Repository, votersRepo *voters.Repository, message *tgbotapi.Message, pollID string) {
-	// Get current voters
-	voters, err := votersRepo.GetComingVoters(ctx, pollID)
-	if err != nil {
-		log.Printf("Error getting voters: %v", err)
+func handleQueueAdvance(ctx context.Context, bot telegram.Client, perms *permissions.Checker, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string, status string) {
+	p := i18n.ForUser(ctx)
+	// Extract poll_id from callback data
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+	pollID := parts[1]
+	if !pollInChat(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Опрос не найден")))
+		return
+	}
+
+	if !isPollHost(ctx, perms, pollsRepo, callback.Message.Chat.ID, pollID, callback.From.ID) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, p.T(pollManageDenied)))
 		return
 	}
 
-	// Get poll topic
-	topic, err := pollsRepo.GetPollTopic(ctx, pollID)
+	next, err := votersRepo.AdvanceQueue(ctx, pollID, status)
+	if errors.Is(err, voters.ErrQueueFinished) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("🏁 Очередь уже завершена")))
+		return
+	}
 	if err != nil {
-		log.Printf("Error getting poll topic: %v", err)
-		topic = "Опрос" // fallback
+		log.Printf("Error advancing queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
+		return
 	}
 
-	// Format updated results
-	text := formatQueueResults(topic, voters)
+	// Update the results message
+	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, chatsRepo, callback.Message.Chat.ID, callback.Message.MessageID, pollID, lineup.CurrentPage)
+
+	// Ping the person who presents now
+	if next != nil {
+		cp := i18n.ForChat(ctx)
+		ping := tgbotapi.NewMessage(callback.Message.Chat.ID, lineup.Ping(cp, next.TelegramVoterDTO))
+		ping.ParseMode = render.ParseMode
+		ping.ReplyToMessageID = callback.Message.MessageID
+		bot.Send(ping)
+	}
 
-	// Create inline keyboard for queue management
-	keyboard := createQueueKeyboard(pollID)
+	confirmText := p.T("✅ Отмечено")
+	if status == voters.QueueStatusSkipped {
+		confirmText = p.T("⏭ Пропущено")
+	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, confirmText))
+}
 
-	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, text)
-	edit.ParseMode = "Markdown"
-	edit.ReplyMarkup = &keyboard
-	bot.Send(edit)
+// pollInChat reports whether the poll belongs to the chat, so a button
+// cannot act on a poll of another chat through forged data.
+func pollInChat(ctx context.Context, pollsRepo *polls.Repository, pollID string, chatID int64) bool {
+	poll, err := pollsRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting poll: %v", err)
+		return false
+	}
+	return poll.ChatID == chatID
 }
 
-func createQueueKeyboard(pollID string) tgbotapi.InlineKeyboardMarkup {
-	return tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🙋 Войти", fmt.Sprintf("queue_join:%s", pollID)),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти", fmt.Sprintf("queue_exit:%s", pollID)),
-		),
-	)
+// queueOpen reports whether the poll belongs to the chat and its queue has
+// been drawn, so joining or leaving cannot touch the queue of a poll that is
+// still running.
+func queueOpen(ctx context.Context, pollsRepo *polls.Repository, pollID string, chatID int64) bool {
+	poll, err := pollsRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting poll: %v", err)
+		return false
+	}
+	if poll.ChatID != chatID {
+		return false
+	}
+	return poll.Status == polls.PollStatusPosted || poll.Status == polls.PollStatusProcessed
 }
 
-func formatQueueResults(topic string, voters []voters.TelegramVoterDTO) string {
-	var sb strings.Builder
-	sb.WriteString("🎯 *Результаты опроса:* ")
-	sb.WriteString(topic)
-	sb.WriteString("\n\n")
-
-	if len(voters) == 0 {
-		sb.WriteString("😔 *Никто не идет*\n\n")
-		sb.WriteString("💡 Используйте кнопки ниже, чтобы присоединиться к очереди!")
-		return sb.String()
-	}
-
-	sb.WriteString(fmt.Sprintf("👥 *Участников:* %d\n\n", len(voters)))
-	sb.WriteString("🏆 *Очередь участников:*\n")
-
-	for i, voter := range voters {
-		sb.WriteString(fmt.Sprintf("%d. ", i+1))
-		if voter.Username != "" {
-			sb.WriteString("@")
-			sb.WriteString(voter.Username)
-			if voter.Name != "" {
-				sb.WriteString(" (")
-				sb.WriteString(voter.Name)
-				sb.WriteString(")")
-			}
-		} else if voter.Name != "" {
-			sb.WriteString(voter.Name)
-		} else {
-			sb.WriteString("Аноним")
-		}
-		sb.WriteString("\n")
+// isPollHost reports whether the user may drive the queue: the poll creator or
+// anyone with the host role.
+func isPollHost(ctx context.Context, perms *permissions.Checker, pollsRepo *polls.Repository, chatID int64, pollID string, userID int64) bool {
+	creatorID, err := pollsRepo.GetPollCreator(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting poll creator: %v", err)
 	}
+	return perms.CanManagePoll(ctx, chatID, userID, creatorID)
+}
 
-	sb.WriteString("\n💡 *Используйте кнопки ниже для управления очередью*")
-	return sb.String()
+// handleQueuePage handles the "◀ / ▶" buttons of a results message.
+func handleQueuePage(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	pollID, page, ok := parseQueueData(data)
+	if !ok {
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	}
+	if !pollInChat(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, i18n.ForUser(ctx).T("❌ Опрос не найден")))
+		return
+	}
+	updateQueueMessage(ctx, bot, pollsRepo, votersRepo, chatsRepo, callback.Message.Chat.ID, callback.Message.MessageID, pollID, page)
+	bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 }
 
-func formatDuration(d time.Duration) string {
-	hours := int(d.Hours())
-	minutes := int(d.Minutes()) % 60
+// parseQueueData parses "<action>:<poll id>[:<page>]" button data. Buttons
+// of messages posted before pagination carry no page and open the first.
+func parseQueueData(data string) (pollID string, page int, ok bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", 0, false
+	}
+	if len(parts) == 3 {
+		var err error
+		if page, err = strconv.Atoi(parts[2]); err != nil {
+			return "", 0, false
+		}
+	}
+	return parts[1], page, true
+}
 
-	if hours > 0 && minutes > 0 {
-		return fmt.Sprintf("%d ч. %d мин.", hours, minutes)
-	} else if hours > 0 {
-		return fmt.Sprintf("%d ч.", hours)
-	} else {
-		return fmt.Sprintf("%d мин.", minutes)
+// updateQueueMessage redraws a results message on page, which may be
+// lineup.CurrentPage.
+func updateQueueMessage(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, chatID int64, messageID int, pollID string, page int) {
+	p := i18n.ForChat(ctx)
+	// Get current queue in its stored order
+	entries, err := votersRepo.GetQueue(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting voters: %v", err)
+		return
+	}
+	current, err := votersRepo.GetCurrentPosition(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting current position: %v", err)
+		return
+	}
+
+	// Get the draw data; polls finished before seeds were introduced have none
+	result, err := votersRepo.GetPollResult(ctx, pollID)
+	if err != nil || result.SeedSecret == "" {
+		result = nil
+	}
+
+	// Get poll topic and capacity
+	poll, err := pollsRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting poll: %v", err)
+		poll = &polls.TelegramPollDTO{PollID: pollID, Topic: p.T("Опрос")} // fallback
+	}
+
+	m := lineup.Message{
+		Poll:     poll,
+		Entries:  entries,
+		Current:  current,
+		Result:   result,
+		Location: chatLocation(ctx, chatsRepo, chatID),
+		Page:     page,
+		Printer:  p,
+	}
+	res := lineup.ForChat(ctx, chatsRepo, chatID).Text(m)
+	keyboard := lineup.Keyboard(m, res)
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, res.Text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+// formatCapacity renders the participant limit of a poll.
+func formatCapacity(p *i18n.Printer, capacity int) string {
+	if capacity == 0 {
+		return p.T("без ограничений")
 	}
+	return strconv.Itoa(capacity)
 }
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+)
+
+func TestPollConfirmationEscapesTopic(t *testing.T) {
+	state := &PollCreationState{
+		Topic:           "<script>alert(1)</script> & *bold* > ivan_petrov",
+		Duration:        45 * time.Minute,
+		MaxParticipants: 20,
+		SessionStart:    time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC),
+		SlotLength:      10 * time.Minute,
+	}
+	tests := []struct {
+		lang i18n.Lang
+		want string
+	}{
+		{i18n.Russian, "✅ <b>Подтверждение опроса</b>\n\n" +
+			"📋 <b>Тема:</b> &lt;script&gt;alert(1)&lt;/script&gt; &amp; *bold* &gt; ivan_petrov\n" +
+			"⏰ <b>Длительность:</b> 45 минут\n" +
+			"👥 <b>Мест:</b> 20\n" +
+			"🗓 <b>Начало занятия:</b> 18:30 17.10.2026 UTC, по 10 минут на человека\n\n" +
+			"Всё правильно?"},
+		{i18n.English, "✅ <b>Confirm the poll</b>\n\n" +
+			"📋 <b>Topic:</b> &lt;script&gt;alert(1)&lt;/script&gt; &amp; *bold* &gt; ivan_petrov\n" +
+			"⏰ <b>Duration:</b> 45 minutes\n" +
+			"👥 <b>Places:</b> 20\n" +
+			"🗓 <b>Session start:</b> 18:30 17.10.2026 UTC, 10 minutes per person\n\n" +
+			"Is everything right?"},
+	}
+	for _, tt := range tests {
+		got, _ := pollConfirmation(i18n.For(tt.lang), state, time.UTC)
+		if got != tt.want {
+			t.Errorf("%s: pollConfirmation =\n%s\nwant:\n%s", tt.lang, got, tt.want)
+		}
+	}
+}
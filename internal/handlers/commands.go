@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// commandMenu lists the commands shown in the menu of group chats, with
+// their descriptions
+var commandMenu = []struct {
+	name, description string
+}{
+	{"poll", "Создать опрос"},
+	{"swap", "Поменяться местами в очереди"},
+	{"verify", "Проверить жеребьёвку"},
+	{"close", "Завершить опрос"},
+	{"extend", "Продлить опрос"},
+	{"cancel", "Отменить опрос"},
+	{"schedule", "Регулярные опросы"},
+	{"timezone", "Часовой пояс чата"},
+	{"language", "Язык чата"},
+	{"strategy", "Порядок очереди"},
+	{"role", "Роли участников"},
+	{"template", "Шаблон строки очереди"},
+	{"settings", "Настройки чата"},
+}
+
+// SetCommands registers the command menu of group chats in every supported
+// language. Users whose Telegram speaks none of them see the default one.
+func SetCommands(bot telegram.Client) error {
+	scope := tgbotapi.NewBotCommandScopeAllGroupChats()
+	for _, lang := range i18n.Supported {
+		p := i18n.For(lang)
+		cmds := make([]tgbotapi.BotCommand, len(commandMenu))
+		for i, c := range commandMenu {
+			cmds[i] = tgbotapi.BotCommand{Command: c.name, Description: p.T(c.description)}
+		}
+		configs := []tgbotapi.SetMyCommandsConfig{tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, string(lang), cmds...)}
+		if lang == i18n.Default {
+			configs = append(configs, tgbotapi.NewSetMyCommandsWithScope(scope, cmds...))
+		}
+		for _, cfg := range configs {
+			if _, err := bot.Request(cfg); err != nil {
+				return fmt.Errorf("set %s commands: %w", lang, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// handleLanguageCommand handles "/language [ru|en]". Without arguments it
+// shows the chat language; changing it is restricted to chat admins.
+func handleLanguageCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+	p := i18n.ForUser(ctx)
+
+	usage := make([]string, len(i18n.Supported))
+	for i, l := range i18n.Supported {
+		usage[i] = string(render.Code("/language " + string(l)))
+	}
+	choices := render.HTML(strings.Join(usage, ", "))
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" {
+		reply(p.HTML("🌐 <b>Язык чата:</b> %s\n\nИзменить: %s", chatLanguage(ctx, chatsRepo, msg.Chat.ID).Name(), choices))
+		return
+	}
+
+	lang, ok := i18n.Parse(name)
+	if !ok {
+		reply(p.HTML("❌ Неизвестный язык. Доступны: %s", choices))
+		return
+	}
+	if !perms.IsAdmin(ctx, msg.Chat.ID, msg.From.ID) {
+		reply(p.T("⛔ Менять язык чата могут только администраторы чата."))
+		return
+	}
+	if err := chatsRepo.SetLanguage(ctx, msg.Chat.ID, lang); err != nil {
+		log.Printf("Error saving chat language: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	// The chat now speaks lang, so the confirmation does too
+	reply(i18n.For(lang).HTML("✅ <b>Язык чата:</b> %s", lang.Name()))
+}
@@ -4,126 +4,217 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
 	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
 )
 
-func HandleMessage(
+// handleTextMessage handles group messages that are not registered commands:
+// values typed into the settings or poll wizards and polls requested by
+// mentioning the bot.
+func handleTextMessage(
 	ctx context.Context,
-	bot *tgbotapi.BotAPI,
+	bot telegram.Client,
+	perms *permissions.Checker,
 	store *polls.Repository,
+	chatsRepo *chats.Repository,
+	states conversations.Store,
 	msg *tgbotapi.Message,
 	botUsername string,
 	pollsService polls.Service,
 ) {
-	if msg.Chat == nil || (msg.Chat.Type != "group" && msg.Chat.Type != "supergroup") {
+	if msg.Text == "" || msg.From == nil {
 		return
 	}
-	text := msg.Text
-	if text == "" {
+
+	// Check if an admin is typing a new setting value
+	if handleSettingsInput(ctx, bot, states, chatsRepo, msg) {
 		return
 	}
 
 	// Check if user is in poll creation flow
-	if handlePollCreationInput(ctx, bot, store, msg, pollsService) {
+	if handlePollCreationInput(ctx, bot, states, store, chatsRepo, msg, pollsService) {
 		return
 	}
 
-	// Trigger on /poll command or mention of bot username
-	triggered := false
-	if msg.IsCommand() && msg.Command() == "poll" {
-		triggered = true
-		text = msg.CommandArguments()
-	} else if len(msg.Entities) > 0 {
-		for _, e := range msg.Entities {
-			if e.Type == "mention" {
-				mention := msg.Text[e.Offset : e.Offset+e.Length]
-				if mention == "@"+botUsername {
-					triggered = true
-					// Strip mention from text
-					text = msg.Text[e.Offset+e.Length:]
-					break
-				}
+	// Trigger on mention of bot username
+	for _, e := range msg.Entities {
+		if e.Type == "mention" {
+			mention := msg.Text[e.Offset : e.Offset+e.Length]
+			if mention == "@"+botUsername {
+				// Strip mention from text
+				handlePollRequest(ctx, bot, perms, store, chatsRepo, states, msg, msg.Text[e.Offset+e.Length:], pollsService)
+				return
 			}
 		}
 	}
-	if !triggered {
+}
+
+// handlePollRequest creates a poll from text, the arguments of /poll or the
+// message after a mention, or starts the wizard when there are none.
+func handlePollRequest(
+	ctx context.Context,
+	bot telegram.Client,
+	perms *permissions.Checker,
+	store *polls.Repository,
+	chatsRepo *chats.Repository,
+	states conversations.Store,
+	msg *tgbotapi.Message,
+	text string,
+	pollsService polls.Service,
+) {
+	p := i18n.ForUser(ctx)
+	if !perms.CanCreatePolls(ctx, msg.Chat.ID, msg.From.ID) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, pollCreateDenied(p, perms.PollCreatorRole(ctx, msg.Chat.ID)))
+		reply.ReplyToMessageID = msg.MessageID
+		bot.Send(reply)
 		return
 	}
 
 	// If no arguments provided, show interactive poll creation
 	if strings.TrimSpace(text) == "" {
-		showInteractivePollCreation(ctx, bot, msg.Chat.ID, msg.From.ID)
+		showInteractivePollCreation(ctx, bot, states, chatsRepo, msg.Chat.ID, msg.From.ID)
 		return
 	}
 
-	// Legacy support: parse old format "Topic | 30m"
-	topic, dur, err := parseTopicAndDuration(text)
+	// Legacy support: parse old format "Topic | 30m", optionally followed by
+	// "| 20" places and "| 18:30 10m" session start and slot length
+	loc := chatLocation(ctx, chatsRepo, msg.Chat.ID)
+	opts, err := parsePollArgs(text, loc, time.Now())
 	if err != nil {
-		reply := tgbotapi.NewMessage(msg.Chat.ID, "💡 *Создание опроса*\n\nИспользуйте команду `/poll` без параметров для интерактивного создания опроса.\n\nИли используйте старый формат: `/poll Тема | 30m`")
-		reply.ParseMode = "Markdown"
+		reply := tgbotapi.NewMessage(msg.Chat.ID, p.HTML("💡 <b>Создание опроса</b>\n\nИспользуйте команду <code>/poll</code> без параметров для интерактивного создания опроса.\n\nИли используйте старый формат: <code>/poll Тема | 30m</code>, <code>/poll Тема | 30m | 20</code>, где 20 — число мест (0 — без ограничений), или <code>/poll Тема | 30m | 20 | 18:30 10m</code> с временем начала занятия и длительностью выступления"))
+		reply.ParseMode = render.ParseMode
+		reply.ReplyToMessageID = msg.MessageID
+		bot.Send(reply)
+		return
+	}
+
+	settings := chatSettings(ctx, chatsRepo, msg.Chat.ID)
+	if !settings.DurationAllowed(opts.Duration) {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Длительность должна быть от %s до %s",
+			p.Duration(settings.MinDuration), p.Duration(settings.MaxDuration)))
 		reply.ReplyToMessageID = msg.MessageID
 		bot.Send(reply)
 		return
 	}
 
 	// Create poll using legacy format
-	createPoll(ctx, bot, store, msg, topic, dur, pollsService)
+	createPoll(ctx, bot, store, msg, opts, settings, loc, pollsService)
+}
+
+// maxPollCapacity is the largest participant limit a poll can have
+const maxPollCapacity = 1000
+
+// pollArgs are the options of the legacy one-line /poll syntax
+type pollArgs struct {
+	Topic        string
+	Duration     time.Duration
+	Capacity     int
+	SessionStart time.Time
+	SlotLength   time.Duration
 }
 
-func parseTopicAndDuration(s string) (string, time.Duration, error) {
-	// Expect format: "Topic | 30m" or "Topic 30m"
+func parsePollArgs(s string, loc *time.Location, now time.Time) (*pollArgs, error) {
+	// Expect format: "Topic | 30m [| 20 [| 18:30 10m]]" or "Topic 30m"
 	// We'll split on '|' first; if not present, split by last space
 	raw := s
 	// Trim leading/trailing spaces
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
-		return "", 0, fmt.Errorf("empty input")
+		return nil, fmt.Errorf("empty input")
 	}
-	if i := strings.Index(raw, "|"); i >= 0 {
-		topic := strings.TrimSpace(raw[:i])
-		durStr := strings.TrimSpace(raw[i+1:])
-		dur, err := time.ParseDuration(durStr)
-		if err != nil || topic == "" {
-			return "", 0, fmt.Errorf("bad format")
+	if strings.Contains(raw, "|") {
+		parts := strings.Split(raw, "|")
+		if len(parts) > 4 {
+			return nil, fmt.Errorf("bad format")
+		}
+		args := &pollArgs{Topic: strings.TrimSpace(parts[0])}
+		dur, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || args.Topic == "" {
+			return nil, fmt.Errorf("bad format")
+		}
+		args.Duration = dur
+		if len(parts) >= 3 {
+			args.Capacity, err = strconv.Atoi(strings.TrimSpace(parts[2]))
+			if err != nil || args.Capacity < 0 || args.Capacity > maxPollCapacity {
+				return nil, fmt.Errorf("bad capacity")
+			}
 		}
-		return topic, dur, nil
+		if len(parts) == 4 {
+			args.SessionStart, args.SlotLength, err = parseSchedule(parts[3], loc, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return args, nil
 	}
 	// No pipe, use last space
 	lastSpace := strings.LastIndex(raw, " ")
 	if lastSpace < 0 {
-		return "", 0, fmt.Errorf("bad format")
+		return nil, fmt.Errorf("bad format")
 	}
 	topic := strings.TrimSpace(raw[:lastSpace])
 	durStr := strings.TrimSpace(raw[lastSpace+1:])
 	dur, err := time.ParseDuration(durStr)
 	if err != nil || topic == "" {
-		return "", 0, fmt.Errorf("bad format")
+		return nil, fmt.Errorf("bad format")
 	}
-	return topic, dur, nil
+	return &pollArgs{Topic: topic, Duration: dur}, nil
 }
 
-func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *polls.Repository, msg *tgbotapi.Message, pollsService polls.Service) bool {
-	stateKey := fmt.Sprintf("%d_%d", msg.Chat.ID, msg.From.ID)
-	state, exists := pollCreationStates[stateKey]
-	if !exists {
-		return false
+// parseSchedule parses "18:30 10m" or "25.12 18:30 10m" into the session
+// start in loc and the slot length per person. Without a date the next
+// occurrence of the time is used.
+func parseSchedule(s string, loc *time.Location, now time.Time) (time.Time, time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return time.Time{}, 0, fmt.Errorf("bad schedule")
+	}
+	slot, err := time.ParseDuration(fields[len(fields)-1])
+	if err != nil || slot < time.Minute || slot > 24*time.Hour {
+		return time.Time{}, 0, fmt.Errorf("bad slot length")
 	}
 
-	// Security: Ensure only the poll creator can input topic
-	// The state should contain the original creator's user ID
-	// Extract creator ID from the state key
-	var creatorID int64
-	var chatID int64
-	if _, err := fmt.Sscanf(stateKey, "%d_%d", &chatID, &creatorID); err != nil {
-		return false
+	now = now.In(loc)
+	var start time.Time
+	if len(fields) == 3 {
+		t, err := time.ParseInLocation("02.01 15:04", fields[0]+" "+fields[1], loc)
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("bad start time")
+		}
+		start = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+		if start.Before(now) {
+			start = start.AddDate(1, 0, 0)
+		}
+	} else {
+		t, err := time.ParseInLocation("15:04", fields[0], loc)
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("bad start time")
+		}
+		start = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+		if start.Before(now) {
+			start = start.AddDate(0, 0, 1)
+		}
 	}
+	return start.UTC(), slot, nil
+}
 
-	// Only the original poll creator can input custom values
-	if msg.From.ID != creatorID {
+func handlePollCreationInput(ctx context.Context, bot telegram.Client, states conversations.Store, store *polls.Repository, chatsRepo *chats.Repository, msg *tgbotapi.Message, pollsService polls.Service) bool {
+	p := i18n.ForUser(ctx)
+	// States are kept per user, so only the poll creator can input custom values
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	state, exists := loadPollState(ctx, states, chatID, userID)
+	if !exists {
 		return false
 	}
 
@@ -131,7 +222,7 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 		// User entered topic
 		topic := strings.TrimSpace(msg.Text)
 		if topic == "" {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Тема не может быть пустой. Попробуйте ещё раз:")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Тема не может быть пустой. Попробуйте ещё раз:"))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
@@ -139,44 +230,19 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 
 		state.Topic = topic
 		state.Step = "duration"
+		savePollState(ctx, states, chatID, userID, state)
 
 		// Update the initial poll creation message to remove cancel button
 		if state.MessageID != 0 {
-			updatedText := fmt.Sprintf("📝 *Создание опроса*\n\n✅ **Тема:** %s", topic)
+			updatedText := p.HTML("📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s", topic)
 			edit := tgbotapi.NewEditMessageText(msg.Chat.ID, state.MessageID, updatedText)
-			edit.ParseMode = "Markdown"
+			edit.ParseMode = render.ParseMode
 			edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 			bot.Send(edit)
 		}
 
 		// Show duration selection
-		text := fmt.Sprintf("⏰ *Выбор длительности опроса*\n\n📋 **Тема:** %s\n\nВыберите длительность:", topic)
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("⏱ 15 минут", "poll_duration:15m"),
-				tgbotapi.NewInlineKeyboardButtonData("⏰ 30 минут", "poll_duration:30m"),
-			),
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🕐 1 час", "poll_duration:1h"),
-				tgbotapi.NewInlineKeyboardButtonData("🕕 2 часа", "poll_duration:2h"),
-			),
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🕘 4 часа", "poll_duration:4h"),
-				tgbotapi.NewInlineKeyboardButtonData("🌅 12 часов", "poll_duration:12h"),
-			),
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("📅 1 день", "poll_duration:24h"),
-			),
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-			),
-		)
-
-		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
-		reply.ParseMode = "Markdown"
-		reply.ReplyMarkup = keyboard
-		bot.Send(reply)
+		showDurationSelection(ctx, bot, states, chatsRepo, msg.Chat.ID, 0, msg.From.ID, topic)
 		return true
 	}
 
@@ -184,7 +250,7 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 		// User entered custom topic
 		topic := strings.TrimSpace(msg.Text)
 		if topic == "" {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Тема не может быть пустой. Попробуйте ещё раз:")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Тема не может быть пустой. Попробуйте ещё раз:"))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
@@ -192,7 +258,7 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 
 		// Basic validation - reasonable length
 		if len(topic) > 100 {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Тема слишком длинная. Максимум: 100 символов.")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Тема слишком длинная. Максимум: 100 символов."))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
@@ -200,18 +266,19 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 
 		state.Topic = topic
 		state.Step = "duration"
+		savePollState(ctx, states, chatID, userID, state)
 
 		// Update the initial poll creation message to remove buttons and show selected topic
 		if state.MessageID != 0 {
-			updatedText := fmt.Sprintf("📝 *Создание опроса*\n\n✅ **Тема:** %s", topic)
+			updatedText := p.HTML("📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s", topic)
 			edit := tgbotapi.NewEditMessageText(msg.Chat.ID, state.MessageID, updatedText)
-			edit.ParseMode = "Markdown"
+			edit.ParseMode = render.ParseMode
 			edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 			bot.Send(edit)
 		}
 
 		// Show duration selection
-		showDurationSelection(ctx, bot, msg.Chat.ID, 0, msg.From.ID, topic)
+		showDurationSelection(ctx, bot, states, chatsRepo, msg.Chat.ID, 0, msg.From.ID, topic)
 		return true
 	}
 
@@ -219,7 +286,7 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 		// User entered custom duration
 		durationStr := strings.TrimSpace(msg.Text)
 		if durationStr == "" {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Длительность не может быть пустой. Попробуйте ещё раз:")
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Длительность не может быть пустой. Попробуйте ещё раз:"))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
@@ -228,91 +295,109 @@ func handlePollCreationInput(ctx context.Context, bot *tgbotapi.BotAPI, store *p
 		// Validate and parse duration
 		duration, err := time.ParseDuration(durationStr)
 		if err != nil {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Неверный формат длительности. Используйте формат: `30m`, `2h`, `1h30m`\n\nПопробуйте ещё раз:")
-			reply.ParseMode = "Markdown"
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.HTML("❌ Неверный формат длительности. Используйте формат: <code>30m</code>, <code>2h</code>, <code>1h30m</code>\n\nПопробуйте ещё раз:"))
+			reply.ParseMode = render.ParseMode
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
 		}
 
-		// Check reasonable duration limits (1 minute to 7 days)
-		if duration < time.Minute {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Длительность слишком короткая. Минимум: 1 минута.")
+		// Check the duration limits of the chat
+		settings := chatSettings(ctx, chatsRepo, chatID)
+		if duration < settings.MinDuration {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Длительность слишком короткая. Минимум: %s", p.Duration(settings.MinDuration)))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
 		}
-		if duration > 7*24*time.Hour {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, "❌ Длительность слишком большая. Максимум: 7 дней.")
+		if duration > settings.MaxDuration {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Длительность слишком большая. Максимум: %s", p.Duration(settings.MaxDuration)))
 			reply.ReplyToMessageID = msg.MessageID
 			bot.Send(reply)
 			return true
 		}
 
-		formattedDur := formatDuration(duration)
-		updatedText := fmt.Sprintf("📝 *Создание опроса*\n\n✅ **Тема:** %s\n⏰ **Длительность:** %s\n", state.Topic, formattedDur)
+		formattedDur := p.Duration(duration)
+		updatedText := p.HTML("📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s\n⏰ <b>Длительность:</b> %s\n", state.Topic, formattedDur)
 		edit := tgbotapi.NewEditMessageText(chatID, state.MessageID, updatedText)
-		edit.ParseMode = "Markdown"
+		edit.ParseMode = render.ParseMode
 		edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 		bot.Send(edit)
 
 		state.Duration = duration
 		state.Step = "confirm"
+		savePollState(ctx, states, chatID, userID, state)
 
 		// Show confirmation (clean interface without navigation buttons after custom input)
-		text := fmt.Sprintf("✅ *Подтверждение опроса*\n\n📋 **Тема:** %s\n⏰ **Длительность:** %s\n\nВсё правильно?",
-			state.Topic, formattedDur)
-
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("✅ Создать", "poll_confirm"),
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "poll_back"),
-			),
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-			),
-		)
-
-		reply := tgbotapi.NewMessage(msg.Chat.ID, text)
-		reply.ParseMode = "Markdown"
-		reply.ReplyMarkup = keyboard
-		bot.Send(reply)
+		sendPollConfirmation(ctx, bot, states, chatsRepo, chatID, userID, state)
+		return true
+	}
+
+	if state.Step == "schedule_custom" {
+		// User entered session start and slot length
+		start, slot, err := parseSchedule(msg.Text, chatLocation(ctx, chatsRepo, chatID), time.Now())
+		if err != nil {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.HTML("❌ Неверный формат. Используйте: <code>18:30 10m</code> или <code>25.12 18:30 10m</code>\n\nПопробуйте ещё раз:"))
+			reply.ParseMode = render.ParseMode
+			reply.ReplyToMessageID = msg.MessageID
+			bot.Send(reply)
+			return true
+		}
+
+		state.SessionStart = start
+		state.SlotLength = slot
+		state.Step = "confirm"
+		savePollState(ctx, states, chatID, userID, state)
+
+		sendPollConfirmation(ctx, bot, states, chatsRepo, chatID, userID, state)
+		return true
+	}
+
+	if state.Step == "capacity_custom" {
+		// User entered custom participant limit
+		capacity, err := strconv.Atoi(strings.TrimSpace(msg.Text))
+		if err != nil || capacity < 1 || capacity > maxPollCapacity {
+			reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Введите число от 1 до %d:", maxPollCapacity))
+			reply.ReplyToMessageID = msg.MessageID
+			bot.Send(reply)
+			return true
+		}
+
+		state.MaxParticipants = capacity
+		state.Step = "confirm"
+		savePollState(ctx, states, chatID, userID, state)
+
+		sendPollConfirmation(ctx, bot, states, chatsRepo, chatID, userID, state)
 		return true
 	}
 
 	return false
 }
 
-func showInteractivePollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, userID int64) {
-	stateKey := fmt.Sprintf("%d_%d", chatID, userID)
-
-	text := "📝 *Создание опроса*\n\nВыберите тему опроса или введите свою:"
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Анализ данных", "poll_topic:Анализ данных"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔒 Информационная безопасность", "poll_topic:Информационная безопасность"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🤖 Промпт инжениринг", "poll_topic:Промпт инжениринг"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🎨 Интерфейсы", "poll_topic:Интерфейсы"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🏛️ Сбер", "poll_topic:Сбер"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✏️ Свое значение", "poll_topic_custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "poll_cancel"),
-		),
-	)
+// sendPollConfirmation posts the confirmation step as a new message, which
+// becomes the wizard message only its owner can use.
+func sendPollConfirmation(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, userID int64, state *PollCreationState) {
+	p := i18n.ForUser(ctx)
+	text, keyboard := pollConfirmation(p, state, chatLocation(ctx, chatsRepo, chatID))
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ParseMode = render.ParseMode
+	reply.ReplyMarkup = keyboard
+	sent, err := bot.Send(reply)
+	if err != nil {
+		log.Printf("Error sending poll confirmation: %v", err)
+		return
+	}
+	state.MessageID = sent.MessageID
+	savePollState(ctx, states, chatID, userID, state)
+}
+
+func showInteractivePollCreation(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, chatID int64, userID int64) {
+	p := i18n.ForUser(ctx)
+	text := p.HTML("📝 <b>Создание опроса</b>\n\nВыберите тему опроса или введите свою:")
+	keyboard := topicKeyboard(p, chatSettings(ctx, chatsRepo, chatID))
 
 	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = "Markdown"
+	msg.ParseMode = render.ParseMode
 	msg.ReplyMarkup = keyboard
 	sent, err := bot.Send(msg)
 	if err != nil {
@@ -321,62 +406,64 @@ func showInteractivePollCreation(ctx context.Context, bot *tgbotapi.BotAPI, chat
 	}
 
 	// Store state with message ID for later deletion
-	pollCreationStates[stateKey] = &PollCreationState{Step: "topic", MessageID: sent.MessageID}
+	savePollState(ctx, states, chatID, userID, &PollCreationState{Step: "topic", MessageID: sent.MessageID})
 }
 
-func createPoll(ctx context.Context, bot *tgbotapi.BotAPI, store *polls.Repository, msg *tgbotapi.Message, topic string, dur time.Duration, pollsService polls.Service) {
-	// Create enhanced poll question with duration and end time
-	endTime := time.Now().UTC().Add(dur)
-	pollQuestion := fmt.Sprintf("📋 Тема: %s\n⏰ Длительность: %s\n🕐 Завершится: %s",
-		topic,
-		formatDuration(dur),
-		formatTimeInMSK(endTime))
-
-	// Create poll with Russian options
-	pollCfg := tgbotapi.NewPoll(msg.Chat.ID, pollQuestion, []string{"Иду", "Не иду"}...)
-	pollCfg.IsAnonymous = false
-	pollCfg.AllowsMultipleAnswers = false
-	sent, err := bot.Send(pollCfg)
-	if err != nil {
-		log.Printf("send poll error: %v", err)
-		return
-	}
-	if sent.Poll == nil {
-		log.Printf("poll send returned no poll")
-		return
-	}
-	p := &polls.TelegramPollDTO{
-		PollID:          sent.Poll.ID,
+func createPoll(ctx context.Context, bot telegram.Client, store *polls.Repository, msg *tgbotapi.Message, opts *pollArgs, settings *chats.Settings, loc *time.Location, pollsService polls.Service) {
+	p := i18n.ForUser(ctx)
+	_, err := pollcreate.Create(ctx, bot, store, pollsService, pollcreate.Request{
 		ChatID:          msg.Chat.ID,
-		MessageID:       sent.MessageID,
-		Topic:           topic,
+		Topic:           opts.Topic,
+		Duration:        opts.Duration,
+		MaxParticipants: opts.Capacity,
+		SessionStart:    opts.SessionStart,
+		SlotLength:      opts.SlotLength,
 		CreatorID:       msg.From.ID,
 		CreatorUsername: msg.From.UserName,
-		CreatorName: msg.From.FirstName + func() string {
-			if msg.From.LastName != "" {
-				return " " + msg.From.LastName
-			}
-			return ""
-		}(),
-		StartedAt: time.Now().UTC(),
-		Duration:  dur,
-		EndsAt:    time.Now().UTC().Add(dur),
-	}
-	if err := store.InsertPoll(ctx, p); err != nil {
-		log.Printf("insert poll error: %v", err)
-		return
+		CreatorName:     pollcreate.UserName(msg.From),
+		OptionComing:    settings.OptionComing,
+		OptionNotComing: settings.OptionNotComing,
+		Location:        loc,
+		Printer:         i18n.ForChat(ctx),
+	})
+	if err != nil {
+		log.Printf("create poll error: %v", err)
+		reply := tgbotapi.NewMessage(msg.Chat.ID, p.T("❌ Ошибка при создании опроса, опрос не был создан. Попробуйте позже."))
+		reply.ReplyToMessageID = msg.MessageID
+		bot.Send(reply)
 	}
-	// Enqueue async job to finalize poll at EndsAt
-	if pollsService != nil {
-		args := polls.FinishPollArgs{PollID: p.PollID, ChatID: p.ChatID, MessageID: p.MessageID, Topic: p.Topic}
-		if err := pollsService.SchedulePollFinish(ctx, args, p.EndsAt); err != nil {
-			log.Printf("enqueue finish poll error: %v", err)
+}
+
+// chatLocation returns the chat's timezone, falling back to the default one
+// when settings cannot be read.
+func chatLocation(ctx context.Context, chatsRepo *chats.Repository, chatID int64) *time.Location {
+	loc, err := chatsRepo.GetTimezone(ctx, chatID)
+	if err != nil {
+		log.Printf("Error getting chat timezone: %v", err)
+		if loc, err = time.LoadLocation(chats.DefaultTimezone); err != nil {
+			return time.UTC
 		}
 	}
+	return loc
+}
+
+// chatSettings returns the chat settings, falling back to the defaults when
+// they cannot be read.
+func chatSettings(ctx context.Context, chatsRepo *chats.Repository, chatID int64) *chats.Settings {
+	settings, err := chatsRepo.GetSettings(ctx, chatID)
+	if err != nil {
+		log.Printf("Error getting chat settings: %v", err)
+		return chats.DefaultSettings(chatID)
+	}
+	return settings
 }
 
-func formatTimeInMSK(t time.Time) string {
-	// Convert UTC time to Moscow Standard Time (UTC+3)
-	msk := time.FixedZone("MSK", 3*60*60)
-	return t.In(msk).Format("15:04 02.01.2006 MSK")
+// chatLanguage returns the chat's language, falling back to the default one
+// when settings cannot be read.
+func chatLanguage(ctx context.Context, chatsRepo *chats.Repository, chatID int64) i18n.Lang {
+	lang, err := chatsRepo.GetLanguage(ctx, chatID)
+	if err != nil {
+		log.Printf("Error getting chat language: %v", err)
+	}
+	return lang
 }
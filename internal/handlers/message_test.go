@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePollArgs(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in       string
+		topic    string
+		duration time.Duration
+		capacity int
+	}{
+		{"Разбор 30m", "Разбор", 30 * time.Minute, 0},
+		{"Разбор задач 1h30m", "Разбор задач", 90 * time.Minute, 0},
+		{"Разбор | 30m", "Разбор", 30 * time.Minute, 0},
+		{" Разбор  |  30m  |  20 ", "Разбор", 30 * time.Minute, 20},
+		{"Разбор | 30m | 0", "Разбор", 30 * time.Minute, 0},
+		{"Разбор | 30m | 1000", "Разбор", 30 * time.Minute, 1000},
+	}
+	for _, tt := range tests {
+		got, err := parsePollArgs(tt.in, time.UTC, now)
+		if err != nil {
+			t.Errorf("parsePollArgs(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Topic != tt.topic || got.Duration != tt.duration || got.Capacity != tt.capacity {
+			t.Errorf("parsePollArgs(%q) = %q %s %d, want %q %s %d", tt.in,
+				got.Topic, got.Duration, got.Capacity, tt.topic, tt.duration, tt.capacity)
+		}
+	}
+}
+
+func TestParsePollArgsRejects(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []string{
+		"",
+		"   ",
+		"Разбор",
+		"30m",
+		"Разбор soon",
+		"| 30m",
+		"Разбор |",
+		"Разбор | soon",
+		"Разбор | 30m | -1",
+		"Разбор | 30m | 1001",
+		"Разбор | 30m | двадцать",
+		"Разбор | 30m | 20.5",
+		"Разбор | 30m |",
+		"Разбор | 30m | 20 | 18:30 10m | extra",
+	}
+	for _, in := range tests {
+		if got, err := parsePollArgs(in, time.UTC, now); err == nil {
+			t.Errorf("parsePollArgs(%q) = %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, berlin)
+	}
+	tests := []struct {
+		name  string
+		in    string
+		now   time.Time
+		start time.Time
+		slot  time.Duration
+	}{
+		{"later today", "18:30 10m", at(2026, 10, 17, 12, 0), at(2026, 10, 17, 18, 30), 10 * time.Minute},
+		{"already passed today", "09:00 5m", at(2026, 10, 17, 12, 0), at(2026, 10, 18, 9, 0), 5 * time.Minute},
+		{"with a date", "25.12 18:30 1h", at(2026, 10, 17, 12, 0), at(2026, 12, 25, 18, 30), time.Hour},
+		{"date passed this year", "01.03 10:00 15m", at(2026, 10, 17, 12, 0), at(2027, 3, 1, 10, 0), 15 * time.Minute},
+		// The next day is after the end of summer time; the local time stays
+		{"tomorrow over the change", "10:00 10m", at(2026, 10, 25, 11, 0), at(2026, 10, 26, 10, 0), 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, slot, err := parseSchedule(tt.in, berlin, tt.now.UTC())
+			if err != nil {
+				t.Fatalf("parseSchedule(%q): %v", tt.in, err)
+			}
+			if !start.Equal(tt.start) || slot != tt.slot {
+				t.Errorf("parseSchedule(%q) = %s %s, want %s %s", tt.in, start, slot, tt.start.UTC(), tt.slot)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []string{
+		"",
+		"18:30",
+		"10m",
+		"18:30 10m extra words",
+		"25:00 10m",
+		"18.30 10m",
+		"32.12 18:30 10m",
+		"18:30 soon",
+		"18:30 30s",
+		"18:30 25h",
+	}
+	for _, in := range tests {
+		if _, _, err := parseSchedule(in, time.UTC, now); err == nil {
+			t.Errorf("parseSchedule(%q) gave no error", in)
+		}
+	}
+}
+
+func TestParsePollArgsSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	got, err := parsePollArgs("Разбор | 30m | 20 | 18:30 10m", time.UTC, now)
+	if err != nil {
+		t.Fatalf("parsePollArgs: %v", err)
+	}
+	if want := time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC); !got.SessionStart.Equal(want) || got.SlotLength != 10*time.Minute {
+		t.Errorf("schedule = %s %s, want %s 10m", got.SessionStart, got.SlotLength, want)
+	}
+	if _, err := parsePollArgs("Разбор | 30m | 20 | 18:30", time.UTC, now); err == nil {
+		t.Error("parsePollArgs accepted a schedule without a slot length")
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/nikitkaralius/lineup/internal/voters"
 )
 
-func HandlePollAnswer(ctx context.Context, store *voters.Repository, pa *tgbotapi.PollAnswer) {
+func handlePollAnswer(ctx context.Context, store *voters.Repository, pa *tgbotapi.PollAnswer) {
 	// Persist vote
 	_ = store.UpsertVote(ctx, pa.PollID, pa.User, pa.OptionIDs)
 }
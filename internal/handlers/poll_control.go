@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// extendButtonStep is how much time the "➕" button under a poll adds
+const extendButtonStep = 30 * time.Minute
+
+// pollManageDenied is the refusal shown to members driving someone else's poll.
+const pollManageDenied = "⛔ Управлять опросом и очередью могут только автор опроса, ведущие и администраторы чата."
+
+// handlePollControlCommand handles "/close", "/extend 30m" and "/cancel". The
+// poll is taken from the replied poll message or, if there is none, the
+// latest active poll in the chat.
+func handlePollControlCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, pollsRepo *polls.Repository, chatsRepo *chats.Repository, msg *tgbotapi.Message, pollsService polls.Service) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	var (
+		poll *polls.TelegramPollDTO
+		err  error
+	)
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.Poll != nil {
+		poll, err = pollsRepo.FindPollByMessage(ctx, msg.Chat.ID, msg.ReplyToMessage.MessageID)
+	} else {
+		poll, err = pollsRepo.FindLatestActivePoll(ctx, msg.Chat.ID)
+	}
+	if err != nil {
+		log.Printf("Error finding poll for %s: %v", msg.Command(), err)
+		reply(p.T("❌ Активный опрос не найден. Ответьте командой на сообщение с опросом."))
+		return
+	}
+
+	var extendBy time.Duration
+	if msg.Command() == "extend" {
+		extendBy, err = time.ParseDuration(strings.TrimSpace(msg.CommandArguments()))
+		if err != nil || extendBy <= 0 {
+			reply(p.HTML("💡 Использование: <code>/extend 30m</code>"))
+			return
+		}
+	}
+
+	if !isPollHost(ctx, perms, pollsRepo, msg.Chat.ID, poll.PollID, msg.From.ID) {
+		reply(p.T(pollManageDenied))
+		return
+	}
+
+	var text string
+	switch msg.Command() {
+	case "close":
+		text, err = closePoll(ctx, pollsRepo, pollsService, poll)
+	case "extend":
+		text, err = extendPoll(ctx, bot, pollsRepo, chatsRepo, pollsService, poll, extendBy)
+	case "cancel":
+		text, err = cancelPoll(ctx, bot, pollsRepo, pollsService, poll)
+	}
+	if err != nil {
+		log.Printf("Error running /%s: %v", msg.Command(), err)
+		text = pollControlError(p, err)
+	}
+	reply(text)
+}
+
+// handlePollControlCallback handles the buttons under a poll.
+func handlePollControlCallback(ctx context.Context, bot telegram.Client, perms *permissions.Checker, pollsRepo *polls.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string, pollsService polls.Service) {
+	p := i18n.ForUser(ctx)
+	action, pollID, ok := strings.Cut(strings.TrimPrefix(data, "pollctl_"), ":")
+	if !ok {
+		return
+	}
+	chatID := callback.Message.Chat.ID
+	if !isPollHost(ctx, perms, pollsRepo, chatID, pollID, callback.From.ID) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, p.T(pollManageDenied)))
+		return
+	}
+	poll, err := pollsRepo.GetPoll(ctx, pollID)
+	if err != nil || poll.ChatID != chatID {
+		log.Printf("Error getting poll: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Опрос не найден")))
+		return
+	}
+
+	var text string
+	switch action {
+	case "close":
+		text, err = closePoll(ctx, pollsRepo, pollsService, poll)
+	case "extend":
+		text, err = extendPoll(ctx, bot, pollsRepo, chatsRepo, pollsService, poll, extendButtonStep)
+	case "cancel":
+		text, err = cancelPoll(ctx, bot, pollsRepo, pollsService, poll)
+	default:
+		log.Printf("Unknown poll control action: %s", action)
+		return
+	}
+	if err != nil {
+		log.Printf("Error running poll control %s: %v", action, err)
+		text = pollControlError(p, err)
+	}
+	// Callback answers are plain text
+	bot.Request(tgbotapi.NewCallback(callback.ID, strings.NewReplacer("<b>", "", "</b>", "").Replace(text)))
+}
+
+// closePoll ends an active poll now; the finish job posts the lineup.
+func closePoll(ctx context.Context, pollsRepo *polls.Repository, pollsService polls.Service, poll *polls.TelegramPollDTO) (string, error) {
+	p := i18n.ForUser(ctx)
+	if pollsService == nil {
+		return "", errors.New("no polls service")
+	}
+	if err := pollsRepo.UpdateEndsAt(ctx, poll.PollID, time.Now().UTC()); err != nil {
+		return "", err
+	}
+	if poll.FinishJobID != 0 {
+		if err := pollsService.FinishPollNow(ctx, poll.FinishJobID); err != nil {
+			return "", err
+		}
+		return p.T("⏹ Опрос завершён, результаты скоро появятся."), nil
+	}
+	// Polls created before job IDs were stored get a new job; the old one
+	// finds the poll processed and does nothing
+	jobID, err := pollsService.SchedulePollFinish(ctx, poll.FinishArgs(), time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	if err := pollsRepo.SetFinishJobID(ctx, poll.PollID, jobID); err != nil {
+		log.Printf("save finish job error: %v", err)
+	}
+	return p.T("⏹ Опрос завершён, результаты скоро появятся."), nil
+}
+
+// extendPoll moves the end of an active poll by d.
+func extendPoll(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, chatsRepo *chats.Repository, pollsService polls.Service, poll *polls.TelegramPollDTO, d time.Duration) (string, error) {
+	p := i18n.ForUser(ctx)
+	if pollsService == nil {
+		return "", errors.New("no polls service")
+	}
+	settings := chatSettings(ctx, chatsRepo, poll.ChatID)
+	endsAt := poll.EndsAt.Add(d)
+	if endsAt.Sub(poll.StartedAt) > settings.MaxDuration {
+		return p.T("❌ Опрос не может длиться дольше %s", p.Duration(settings.MaxDuration)), nil
+	}
+	if err := pollsRepo.UpdateEndsAt(ctx, poll.PollID, endsAt); err != nil {
+		return "", err
+	}
+	// The scheduled finish job is kept: when it runs at the old end it finds
+	// the poll extended and snoozes until the new one. Cancelling it and
+	// inserting another would not work for a running job, which still counts
+	// as the poll's unique job until it stops. Scheduling here only adds a job
+	// if the poll has none and otherwise returns the live one.
+	jobID, err := pollsService.SchedulePollFinish(ctx, poll.FinishArgs(), endsAt)
+	if err != nil {
+		return "", err
+	}
+	if err := pollsRepo.SetFinishJobID(ctx, poll.PollID, jobID); err != nil {
+		log.Printf("save finish job error: %v", err)
+	}
+
+	// Telegram does not allow editing the question of a sent poll, so the
+	// new end time is posted as a reply to it
+	until := pollcreate.FormatTime(endsAt, chatLocation(ctx, chatsRepo, poll.ChatID))
+	announce := tgbotapi.NewMessage(poll.ChatID, pollExtended(i18n.ForChat(ctx), until))
+	announce.ParseMode = render.ParseMode
+	announce.ReplyToMessageID = poll.MessageID
+	if _, err := bot.Send(announce); err != nil {
+		log.Printf("announce poll extension error: %v", err)
+	}
+	return pollExtended(p, until), nil
+}
+
+// pollExtended announces the new end of a poll.
+func pollExtended(p *i18n.Printer, until string) string {
+	return p.HTML("⏰ <b>Опрос продлён</b> до %s", until)
+}
+
+// cancelPoll stops an active poll without posting a lineup.
+func cancelPoll(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, pollsService polls.Service, poll *polls.TelegramPollDTO) (string, error) {
+	p := i18n.ForUser(ctx)
+	if err := pollsRepo.CancelPoll(ctx, poll.PollID); err != nil {
+		return "", err
+	}
+	if poll.FinishJobID != 0 && pollsService != nil {
+		// The finish job would skip the cancelled poll anyway
+		if err := pollsService.CancelPollFinish(ctx, poll.FinishJobID); err != nil {
+			log.Printf("cancel finish job error: %v", err)
+		}
+	}
+	stopCfg := tgbotapi.NewStopPoll(poll.ChatID, poll.MessageID)
+	stopCfg.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if _, err := bot.Send(stopCfg); err != nil {
+		log.Printf("stop poll error: %v", err)
+	}
+	notice := tgbotapi.NewMessage(poll.ChatID, i18n.ForChat(ctx).T("🗑 Опрос отменён, очередь составляться не будет."))
+	notice.ReplyToMessageID = poll.MessageID
+	bot.Send(notice)
+	return p.T("🗑 Опрос отменён."), nil
+}
+
+func pollControlError(p *i18n.Printer, err error) string {
+	if errors.Is(err, polls.ErrPollNotActive) {
+		return p.T("❌ Опрос уже завершён или отменён.")
+	}
+	return p.T("❌ Ошибка. Попробуйте позже.")
+}
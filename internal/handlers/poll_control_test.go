@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+)
+
+func TestPollExtended(t *testing.T) {
+	// Arguments are escaped even when they are not user content
+	until := "18:30 17.10.2026 <GMT&*>"
+	tests := []struct {
+		lang i18n.Lang
+		want string
+	}{
+		{i18n.Russian, "⏰ <b>Опрос продлён</b> до 18:30 17.10.2026 &lt;GMT&amp;*&gt;"},
+		{i18n.English, "⏰ <b>Poll extended</b> until 18:30 17.10.2026 &lt;GMT&amp;*&gt;"},
+	}
+	for _, tt := range tests {
+		if got := pollExtended(i18n.For(tt.lang), until); got != tt.want {
+			t.Errorf("%s: pollExtended = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/lineup"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// roleNames are the role names used in /role, translated where shown
+var roleNames = map[permissions.Role]string{
+	permissions.RoleMember: "участник",
+	permissions.RoleHost:   "ведущий",
+	permissions.RoleAdmin:  "администратор",
+	permissions.RoleOwner:  "владелец",
+}
+
+// rolePlural names everyone with at least the role
+var rolePlural = map[permissions.Role]string{
+	permissions.RoleMember: "все участники",
+	permissions.RoleHost:   "ведущие и администраторы",
+	permissions.RoleAdmin:  "только администраторы",
+	permissions.RoleOwner:  "только владелец",
+}
+
+const roleUsage = "👥 <b>Роли</b>\n\n" +
+	"Ответьте на сообщение участника:\n" +
+	"<code>/role host</code> — ведущий: закрывает опросы и ведёт очередь\n" +
+	"<code>/role admin</code> — администратор бота (назначает владелец)\n" +
+	"<code>/role member</code> — снять роль\n\n" +
+	"Кто создаёт опросы: <code>/role polls member|host|admin</code>"
+
+// pollCreateDenied is the refusal shown to members who may not create polls.
+func pollCreateDenied(p *i18n.Printer, required permissions.Role) string {
+	return p.T("⛔ Создавать опросы в этом чате могут %s.", p.T(rolePlural[required]))
+}
+
+// handleRoleCommand handles /role: without arguments it lists the granted
+// roles; admins change them by replying to a member's message.
+func handleRoleCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, rolesRepo *permissions.Repository, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	fields := strings.Fields(strings.ToLower(msg.CommandArguments()))
+	if len(fields) == 0 {
+		grants, err := rolesRepo.ListGrants(ctx, msg.Chat.ID)
+		if err != nil {
+			log.Printf("Error listing roles: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		reply(formatRoles(p, grants, perms.PollCreatorRole(ctx, msg.Chat.ID)))
+		return
+	}
+
+	actor := perms.Role(ctx, msg.Chat.ID, msg.From.ID)
+	if actor < permissions.RoleAdmin {
+		reply(p.T("⛔ Менять роли могут только администраторы чата."))
+		return
+	}
+
+	if fields[0] == "polls" {
+		required, ok := parseRoleArg(fields[1:])
+		if !ok || required == permissions.RoleOwner {
+			reply(p.HTML(roleUsage))
+			return
+		}
+		if err := chatsRepo.SetPollCreatorRole(ctx, msg.Chat.ID, required.String()); err != nil {
+			log.Printf("Error saving poll creator role: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		reply(p.T("✅ Создавать опросы могут %s.", p.T(rolePlural[required])))
+		return
+	}
+
+	role, ok := parseRoleArg(fields)
+	target := msg.ReplyToMessage
+	if !ok || role == permissions.RoleOwner || target == nil || target.From == nil || target.From.IsBot {
+		reply(p.HTML(roleUsage))
+		return
+	}
+	if role == permissions.RoleAdmin && actor < permissions.RoleOwner {
+		reply(p.T("⛔ Назначать администраторов может только владелец чата."))
+		return
+	}
+	current := perms.Role(ctx, msg.Chat.ID, target.From.ID)
+	if current == permissions.RoleOwner || (current == permissions.RoleAdmin && actor < permissions.RoleOwner) {
+		reply(p.T("⛔ Роль этого участника может изменить только владелец чата."))
+		return
+	}
+	err := rolesRepo.SetRole(ctx, msg.Chat.ID, permissions.Grant{
+		UserID:    target.From.ID,
+		Username:  target.From.UserName,
+		Name:      pollcreate.UserName(target.From),
+		Role:      role,
+		GrantedBy: msg.From.ID,
+	})
+	if err != nil {
+		log.Printf("Error saving role: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	who := lineup.Mention(p, voters.TelegramVoterDTO{UserID: target.From.ID, Username: target.From.UserName, Name: pollcreate.UserName(target.From)})
+	text := render.Sprintf("✅ %s — %s", who, p.T(roleNames[role]))
+	if now := perms.Role(ctx, msg.Chat.ID, target.From.ID); now > role {
+		// Telegram administrators keep their rights whatever is granted here
+		text += p.T("\nℹ️ В Telegram участник остаётся: %s", p.T(roleNames[now]))
+	}
+	reply(text)
+}
+
+// parseRoleArg parses the single role name in fields.
+func parseRoleArg(fields []string) (permissions.Role, bool) {
+	if len(fields) != 1 {
+		return permissions.RoleMember, false
+	}
+	return permissions.ParseRole(fields[0])
+}
+
+func formatRoles(p *i18n.Printer, grants []permissions.Grant, pollCreator permissions.Role) string {
+	var sb strings.Builder
+	sb.WriteString(p.HTML("👥 <b>Роли в чате</b>\n\n"))
+	sb.WriteString(p.T("👑 Владелец и администраторы Telegram — администраторы\n"))
+	for _, g := range grants {
+		sb.WriteString(render.Sprintf("• %s — %s\n", lineup.Mention(p, voters.TelegramVoterDTO{UserID: g.UserID, Username: g.Username, Name: g.Name}), p.T(roleNames[g.Role])))
+	}
+	sb.WriteString(p.T("\n🗳 Создавать опросы могут %s.\n\n", p.T(rolePlural[pollCreator])))
+	sb.WriteString(p.HTML("Изменить: <code>/role host</code> в ответ на сообщение участника"))
+	return sb.String()
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/router"
+	"github.com/nikitkaralius/lineup/internal/schedules"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// Per-user limits for commands and button presses
+const (
+	userRate  = 1
+	userBurst = 5
+)
+
+// Dependencies are the services the handlers work with.
+type Dependencies struct {
+	Bot          telegram.Client
+	Polls        *polls.Repository
+	Voters       *voters.Repository
+	Chats        *chats.Repository
+	Schedules    *schedules.Repository
+	Roles        *permissions.Repository
+	Permissions  *permissions.Checker
+	States       conversations.Store
+	PollsService polls.Service
+	BotUsername  string
+}
+
+// Register adds all bot commands, buttons and update handlers to r.
+func Register(r *router.Router, d Dependencies) {
+	r.Use(router.Localize(func(ctx context.Context, chatID int64) i18n.Lang {
+		return chatLanguage(ctx, d.Chats, chatID)
+	}))
+
+	groupOnly := router.ChatTypes("group", "supergroup")
+	limited := router.RateLimit(d.Bot, userRate, userBurst)
+	// Most buttons only change the message they belong to; queue, swap, poll
+	// control and settings buttons answer themselves with a status text
+	answer := router.AnswerCallback(d.Bot)
+	adminOnly := router.Authorize(d.Bot, d.Permissions.IsAdmin, settingsDenied)
+	// Denials are translated by Authorize
+	canCreatePolls := router.Authorize(d.Bot, d.Permissions.CanCreatePolls, "⛔ Создавать опросы в этом чате вам нельзя.")
+	ownsWizard := wizardOwner(d.Bot, d.States)
+
+	command := func(name string, h func(ctx context.Context, msg *tgbotapi.Message), mw ...router.Middleware) {
+		r.Command(name, func(ctx context.Context, u *tgbotapi.Update) {
+			if u.Message.From == nil {
+				return
+			}
+			h(ctx, u.Message)
+		}, append([]router.Middleware{groupOnly, limited}, mw...)...)
+	}
+	command("poll", func(ctx context.Context, msg *tgbotapi.Message) {
+		handlePollRequest(ctx, d.Bot, d.Permissions, d.Polls, d.Chats, d.States, msg, msg.CommandArguments(), d.PollsService)
+	})
+	command("swap", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleSwapCommand(ctx, d.Bot, d.Polls, d.Voters, msg, d.PollsService)
+	})
+	command("verify", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleVerifyCommand(ctx, d.Bot, d.Polls, d.Voters, msg)
+	})
+	command("timezone", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleTimezoneCommand(ctx, d.Bot, d.Permissions, d.Chats, msg)
+	})
+	command("schedule", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleScheduleCommand(ctx, d.Bot, d.Permissions, d.Schedules, d.Chats, msg)
+	})
+	for _, name := range []string{"close", "extend", "cancel"} {
+		command(name, func(ctx context.Context, msg *tgbotapi.Message) {
+			handlePollControlCommand(ctx, d.Bot, d.Permissions, d.Polls, d.Chats, msg, d.PollsService)
+		})
+	}
+	command("settings", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleSettingsCommand(ctx, d.Bot, d.Chats, msg)
+	}, adminOnly)
+	command("template", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleTemplateCommand(ctx, d.Bot, d.Chats, msg)
+	}, adminOnly)
+	command("role", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleRoleCommand(ctx, d.Bot, d.Permissions, d.Roles, d.Chats, msg)
+	})
+	command("strategy", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleStrategyCommand(ctx, d.Bot, d.Permissions, d.Chats, msg)
+	})
+	command("language", func(ctx context.Context, msg *tgbotapi.Message) {
+		handleLanguageCommand(ctx, d.Bot, d.Permissions, d.Chats, msg)
+	})
+
+	r.On(router.Message, func(ctx context.Context, u *tgbotapi.Update) {
+		handleTextMessage(ctx, d.Bot, d.Permissions, d.Polls, d.Chats, d.States, u.Message, d.BotUsername, d.PollsService)
+	}, groupOnly)
+
+	callback := func(prefix string, h func(ctx context.Context, cb *tgbotapi.CallbackQuery), mw ...router.Middleware) {
+		r.Callback(prefix, func(ctx context.Context, u *tgbotapi.Update) {
+			// Buttons of inline messages have no chat to work in
+			if u.CallbackQuery.Message == nil {
+				return
+			}
+			h(ctx, u.CallbackQuery)
+		}, append([]router.Middleware{limited}, mw...)...)
+	}
+	// wizard adapts a poll creation step to a callback handler; only the
+	// user who started the wizard may press its buttons
+	wizard := func(prefix string, h func(ctx context.Context, chatID int64, messageID int, userID int64, data string)) {
+		callback(prefix, func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+			h(ctx, cb.Message.Chat.ID, cb.Message.MessageID, cb.From.ID, cb.Data)
+		}, ownsWizard, canCreatePolls, answer)
+	}
+	wizard("poll_topic:", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleTopicSelection(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID, data)
+	})
+	wizard("poll_topic_custom", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCustomTopicInput(ctx, d.Bot, d.States, chatID, messageID, userID)
+	})
+	// Refused durations are reported in the answer, so this step is not
+	// answered upfront
+	callback("poll_duration:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleDurationSelection(ctx, d.Bot, d.States, d.Chats, cb)
+	}, ownsWizard, canCreatePolls)
+	wizard("poll_duration_custom", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCustomDurationInput(ctx, d.Bot, d.States, chatID, messageID, userID)
+	})
+	wizard("poll_confirm", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleConfirmPoll(ctx, d.Bot, d.States, d.Polls, d.Chats, chatID, messageID, userID, d.PollsService)
+	})
+	wizard("poll_back", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleBackToPollCreation(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_back_to_duration", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleBackToDurationSelection(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_capacity:", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCapacitySelection(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID, data)
+	})
+	wizard("poll_capacity_custom", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCustomCapacityInput(ctx, d.Bot, d.States, chatID, messageID, userID)
+	})
+	wizard("poll_schedule_custom", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCustomScheduleInput(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_schedule_clear", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleClearSchedule(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_back_to_confirm", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleBackToConfirm(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_back_to_topic", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleBackToTopicSelection(ctx, d.Bot, d.States, d.Chats, chatID, messageID, userID)
+	})
+	wizard("poll_cancel", func(ctx context.Context, chatID int64, messageID int, userID int64, data string) {
+		handleCancelPollCreation(ctx, d.Bot, d.States, chatID, messageID, userID)
+	})
+
+	callback("queue_exit:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleQueueExit(ctx, d.Bot, d.Polls, d.Voters, d.Chats, cb, cb.Data)
+	})
+	callback("queue_join:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleQueueJoin(ctx, d.Bot, d.Polls, d.Voters, d.Chats, cb, cb.Data)
+	})
+	callback("queue_page:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleQueuePage(ctx, d.Bot, d.Polls, d.Voters, d.Chats, cb, cb.Data)
+	})
+	callback("queue_done:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleQueueAdvance(ctx, d.Bot, d.Permissions, d.Polls, d.Voters, d.Chats, cb, cb.Data, voters.QueueStatusDone)
+	})
+	callback("queue_skip:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleQueueAdvance(ctx, d.Bot, d.Permissions, d.Polls, d.Voters, d.Chats, cb, cb.Data, voters.QueueStatusSkipped)
+	})
+	callback("swap_start:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleSwapStart(ctx, d.Bot, d.Polls, d.Voters, cb, cb.Data)
+	})
+	callback("swap_pick:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleSwapPick(ctx, d.Bot, d.Polls, d.Voters, cb, cb.Data, d.PollsService)
+	})
+	callback("swap_accept:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleSwapAccept(ctx, d.Bot, d.Polls, d.Voters, d.Chats, cb, cb.Data)
+	})
+	callback("swap_decline:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleSwapDecline(ctx, d.Bot, d.Voters, cb, cb.Data)
+	})
+	callback("pollctl_", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handlePollControlCallback(ctx, d.Bot, d.Permissions, d.Polls, d.Chats, cb, cb.Data, d.PollsService)
+	})
+	callback("settings:", func(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+		handleSettingsCallback(ctx, d.Bot, d.States, d.Chats, cb, cb.Data)
+	}, adminOnly, answer)
+
+	r.On(router.CallbackQuery, func(ctx context.Context, u *tgbotapi.Update) {
+		log.Printf("Unknown callback data: %s", u.CallbackQuery.Data)
+	}, answer)
+
+	r.On(router.PollAnswer, func(ctx context.Context, u *tgbotapi.Update) {
+		handlePollAnswer(ctx, d.Voters, u.PollAnswer)
+	})
+}
+
+// wizardOwner lets through only presses on the user's own wizard message, so
+// nobody can drive another member's poll creation.
+func wizardOwner(bot telegram.Client, states conversations.Store) router.Middleware {
+	return func(next router.HandlerFunc) router.HandlerFunc {
+		return func(ctx context.Context, u *tgbotapi.Update) {
+			cb := u.CallbackQuery
+			if cb.Message == nil {
+				return
+			}
+			state, ok := loadPollState(ctx, states, cb.Message.Chat.ID, cb.From.ID)
+			if !ok || state.MessageID != cb.Message.MessageID {
+				bot.Request(tgbotapi.NewCallbackWithAlert(cb.ID, i18n.ForUser(ctx).T("⛔ Это не ваше меню создания опроса или оно устарело. Начните своё: /poll")))
+				return
+			}
+			next(ctx, u)
+		}
+	}
+}
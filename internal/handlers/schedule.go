@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/schedules"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// weekdayNames maps the accepted weekday spellings to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"пн": time.Monday, "вт": time.Tuesday, "ср": time.Wednesday, "чт": time.Thursday,
+	"пт": time.Friday, "сб": time.Saturday, "вс": time.Sunday,
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// weekdayLabels are the short weekday names indexed by time.Weekday,
+// translated where shown
+var weekdayLabels = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+const scheduleUsage = "📅 <b>Регулярные опросы</b>\n\n" +
+	"<code>/schedule Тема | вт 18:00 | 30m</code> — создавать опрос каждую неделю\n" +
+	"<code>/schedule Тема | вт 18:00 | 30m | 20</code> — то же с ограничением мест\n" +
+	"<code>/schedule Тема | вт 18:00</code> — длительность по умолчанию\n" +
+	"<code>/schedule list</code> — список расписаний\n" +
+	"<code>/schedule pause 1</code>, <code>/schedule resume 1</code>, <code>/schedule delete 1</code>"
+
+// handleScheduleCommand handles "/schedule" and its list, pause, resume and
+// delete subcommands. Everything except listing is restricted to chat admins.
+func handleScheduleCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, schedulesRepo *schedules.Repository, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	fields := strings.Fields(args)
+	if len(fields) == 0 || (len(fields) == 1 && strings.ToLower(fields[0]) == "list") {
+		list, err := schedulesRepo.ListSchedules(ctx, msg.Chat.ID)
+		if err != nil {
+			log.Printf("Error listing schedules: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		reply(formatScheduleList(p, list))
+		return
+	}
+
+	if !perms.IsAdmin(ctx, msg.Chat.ID, msg.From.ID) {
+		reply(p.T("⛔ Управлять расписанием могут только администраторы чата."))
+		return
+	}
+
+	action := strings.ToLower(fields[0])
+	if len(fields) == 2 && (action == "pause" || action == "resume" || action == "delete") {
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			reply(p.HTML(scheduleUsage))
+			return
+		}
+		switch action {
+		case "delete":
+			err = schedulesRepo.DeleteSchedule(ctx, msg.Chat.ID, id)
+		default:
+			err = setSchedulePaused(ctx, schedulesRepo, msg.Chat.ID, id, action == "pause")
+		}
+		if errors.Is(err, schedules.ErrScheduleNotFound) {
+			reply(p.T("❌ Расписание #%d не найдено", id))
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating schedule: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		switch action {
+		case "pause":
+			reply(p.T("⏸ Расписание #%d приостановлено", id))
+		case "resume":
+			reply(p.T("▶️ Расписание #%d возобновлено", id))
+		case "delete":
+			reply(p.T("🗑 Расписание #%d удалено", id))
+		}
+		return
+	}
+
+	loc := chatLocation(ctx, chatsRepo, msg.Chat.ID)
+	settings := chatSettings(ctx, chatsRepo, msg.Chat.ID)
+	s, err := parseScheduleArgs(args, settings.DefaultDuration)
+	if err != nil {
+		reply(p.HTML(scheduleUsage))
+		return
+	}
+	if !settings.DurationAllowed(s.Duration) {
+		reply(p.T("❌ Длительность должна быть от %s до %s",
+			p.Duration(settings.MinDuration), p.Duration(settings.MaxDuration)))
+		return
+	}
+	s.ChatID = msg.Chat.ID
+	s.Timezone = loc.String()
+	s.CreatorID = msg.From.ID
+	s.CreatorUsername = msg.From.UserName
+	s.CreatorName = pollcreate.UserName(msg.From)
+	if s.NextRunAt, err = s.NextRun(time.Now()); err != nil {
+		log.Printf("Error computing next schedule run: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	if s.ID, err = schedulesRepo.CreateSchedule(ctx, s); err != nil {
+		log.Printf("Error creating schedule: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	reply(p.HTML("✅ Расписание #%d создано\n\n%s\n\nБлижайший опрос: %s",
+		s.ID, formatSchedule(p, s), pollcreate.FormatTime(s.NextRunAt, loc)))
+}
+
+// setSchedulePaused pauses or resumes a schedule, moving a resumed one to its
+// next occurrence from now.
+func setSchedulePaused(ctx context.Context, schedulesRepo *schedules.Repository, chatID int64, id int64, paused bool) error {
+	s, err := schedulesRepo.GetSchedule(ctx, chatID, id)
+	if err != nil {
+		return err
+	}
+	next := s.NextRunAt
+	if !paused {
+		if next, err = s.NextRun(time.Now()); err != nil {
+			return err
+		}
+	}
+	return schedulesRepo.SetPaused(ctx, chatID, id, paused, next)
+}
+
+// parseScheduleArgs parses "Topic | вт 18:00 [| 30m [| 20]]", using
+// defaultDuration when the duration is omitted.
+func parseScheduleArgs(s string, defaultDuration time.Duration) (*schedules.PollScheduleDTO, error) {
+	parts := strings.Split(s, "|")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, fmt.Errorf("bad format")
+	}
+	p := &schedules.PollScheduleDTO{Topic: strings.TrimSpace(parts[0])}
+	if p.Topic == "" {
+		return nil, fmt.Errorf("empty topic")
+	}
+
+	when := strings.Fields(strings.ToLower(parts[1]))
+	if len(when) != 2 {
+		return nil, fmt.Errorf("bad weekday and time")
+	}
+	weekday, ok := weekdayNames[when[0]]
+	if !ok {
+		return nil, fmt.Errorf("bad weekday")
+	}
+	t, err := time.Parse("15:04", when[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad time")
+	}
+	p.Weekday = weekday
+	p.StartMinute = t.Hour()*60 + t.Minute()
+
+	p.Duration = defaultDuration
+	if len(parts) >= 3 {
+		if p.Duration, err = time.ParseDuration(strings.TrimSpace(parts[2])); err != nil || p.Duration <= 0 {
+			return nil, fmt.Errorf("bad duration")
+		}
+	}
+	if len(parts) == 4 {
+		p.MaxParticipants, err = strconv.Atoi(strings.TrimSpace(parts[3]))
+		if err != nil || p.MaxParticipants < 0 || p.MaxParticipants > maxPollCapacity {
+			return nil, fmt.Errorf("bad capacity")
+		}
+	}
+	return p, nil
+}
+
+func formatSchedule(p *i18n.Printer, s *schedules.PollScheduleDTO) render.HTML {
+	text := p.HTML("📋 %s — каждую неделю, %s %02d:%02d (%s), ⏰ %s",
+		s.Topic, p.T(weekdayLabels[s.Weekday]), s.StartMinute/60, s.StartMinute%60,
+		s.Timezone, p.Duration(s.Duration))
+	if s.MaxParticipants > 0 {
+		text += fmt.Sprintf(", 👥 %d", s.MaxParticipants)
+	}
+	return render.HTML(text)
+}
+
+func formatScheduleList(p *i18n.Printer, list []schedules.PollScheduleDTO) string {
+	if len(list) == 0 {
+		return p.T("📅 Регулярных опросов нет.\n\n") + p.HTML(scheduleUsage)
+	}
+	var sb strings.Builder
+	sb.WriteString(p.HTML("📅 <b>Регулярные опросы:</b>\n\n"))
+	for _, s := range list {
+		status := "▶️"
+		if s.Paused {
+			status = "⏸"
+		}
+		sb.WriteString(render.Sprintf("%s <b>#%d</b> %s\n", status, s.ID, formatSchedule(p, &s)))
+	}
+	return sb.String()
+}
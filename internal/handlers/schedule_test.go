@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleArgs(t *testing.T) {
+	const def = 30 * time.Minute
+	tests := []struct {
+		in       string
+		weekday  time.Weekday
+		minute   int
+		duration time.Duration
+		capacity int
+	}{
+		{"Разбор | вт 18:00", time.Tuesday, 18 * 60, def, 0},
+		{"Разбор | ВТ 18:00 | 1h", time.Tuesday, 18 * 60, time.Hour, 0},
+		{"  Разбор задач  |  sun 09:05  | 45m | 20 ", time.Sunday, 9*60 + 5, 45 * time.Minute, 20},
+		{"Stand-up | Fri 00:00 | 10m | 0", time.Friday, 0, 10 * time.Minute, 0},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleArgs(tt.in, def)
+		if err != nil {
+			t.Errorf("parseScheduleArgs(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Weekday != tt.weekday || got.StartMinute != tt.minute || got.Duration != tt.duration || got.MaxParticipants != tt.capacity {
+			t.Errorf("parseScheduleArgs(%q) = %s %d %s %d, want %s %d %s %d", tt.in,
+				got.Weekday, got.StartMinute, got.Duration, got.MaxParticipants,
+				tt.weekday, tt.minute, tt.duration, tt.capacity)
+		}
+	}
+}
+
+func TestParseScheduleArgsRejects(t *testing.T) {
+	tests := []string{
+		"",
+		"Разбор",
+		"Разбор | вт",
+		" | вт 18:00",
+		"Разбор | 18:00 вт",
+		"Разбор | вторник 18:00",
+		"Разбор | вт 25:00",
+		"Разбор | вт 18:00 extra",
+		"Разбор | вт 18:00 | soon",
+		"Разбор | вт 18:00 | -30m",
+		"Разбор | вт 18:00 | 0s",
+		"Разбор | вт 18:00 | 30m | -1",
+		"Разбор | вт 18:00 | 30m | 1001",
+		"Разбор | вт 18:00 | 30m | many",
+		"Разбор | вт 18:00 | 30m | 20 | 5",
+	}
+	for _, in := range tests {
+		if got, err := parseScheduleArgs(in, 30*time.Minute); err == nil {
+			t.Errorf("parseScheduleArgs(%q) = %+v, want an error", in, got)
+		}
+	}
+}
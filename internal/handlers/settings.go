@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/ordering"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// maxTopics limits the topic buttons shown in the poll wizard
+const maxTopics = 20
+
+// settingsInput remembers which setting an admin is typing a value for
+type settingsInput struct {
+	Field     string `json:"field"`
+	MessageID int    `json:"message_id"`
+}
+
+// settingsFlow names the settings menu in the conversation store
+const settingsFlow = "settings"
+
+// settingsPrompts are shown, translated, when an admin picks a setting to
+// change
+var settingsPrompts = map[string]string{
+	"topics":    "📋 Отправьте темы опросов, по одной на строке.",
+	"durations": "⏰ Отправьте варианты длительности через пробел, например <code>15m 30m* 1h 2h</code>. Звёздочкой отметьте длительность по умолчанию, иначе ею станет первая.",
+	"limits":    "↔️ Отправьте минимальную и максимальную длительность через пробел, например <code>1m 168h</code>.",
+	"timezone":  "🌍 Отправьте часовой пояс в формате IANA, например <code>Europe/Moscow</code>.",
+	"options":   "🗳 Отправьте два варианта ответа, каждый на своей строке: сначала «иду», затем «не иду».",
+}
+
+// settingsDenied is the refusal shown to members opening the settings.
+const settingsDenied = "⛔ Настройки доступны только администраторам чата."
+
+// handleSettingsCommand shows the settings menu; the route lets only chat
+// admins through.
+func handleSettingsCommand(ctx context.Context, bot telegram.Client, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	text, keyboard := settingsMenu(i18n.ForUser(ctx), chatSettings(ctx, chatsRepo, msg.Chat.ID), chatLanguage(ctx, chatsRepo, msg.Chat.ID))
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = render.ParseMode
+	reply.ReplyMarkup = keyboard
+	bot.Send(reply)
+}
+
+// handleSettingsCallback handles the "settings:<field>" buttons of the menu;
+// like the command, the route is restricted to chat admins.
+func handleSettingsCallback(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+
+	key := conversations.Key{ChatID: chatID, UserID: callback.From.ID, Flow: settingsFlow}
+	field := strings.TrimPrefix(data, "settings:")
+	switch field {
+	case "strategy":
+		// Toggle between the two strategies right away
+		settings := chatSettings(ctx, chatsRepo, chatID)
+		next := ordering.StrategyFair
+		if settings.LineupStrategy == ordering.StrategyFair {
+			next = ordering.StrategyUniform
+		}
+		if err := chatsRepo.SetLineupStrategy(ctx, chatID, next); err != nil {
+			log.Printf("Error saving lineup strategy: %v", err)
+			return
+		}
+		showSettingsMenu(ctx, bot, chatsRepo, chatID, messageID)
+	case "language":
+		// Cycle through the supported languages
+		next := i18n.Supported[0]
+		current := chatLanguage(ctx, chatsRepo, chatID)
+		for i, l := range i18n.Supported {
+			if l == current {
+				next = i18n.Supported[(i+1)%len(i18n.Supported)]
+			}
+		}
+		if err := chatsRepo.SetLanguage(ctx, chatID, next); err != nil {
+			log.Printf("Error saving chat language: %v", err)
+			return
+		}
+		showSettingsMenu(ctx, bot, chatsRepo, chatID, messageID)
+	case "back":
+		deleteSettingsInput(ctx, states, key)
+		showSettingsMenu(ctx, bot, chatsRepo, chatID, messageID)
+	case "close":
+		deleteSettingsInput(ctx, states, key)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, p.T("⚙️ Настройки сохранены."))
+		edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+		bot.Send(edit)
+	default:
+		prompt, ok := settingsPrompts[field]
+		if !ok {
+			log.Printf("Unknown settings field: %s", field)
+			return
+		}
+		if err := states.Save(ctx, key, &settingsInput{Field: field, MessageID: messageID}); err != nil {
+			log.Printf("Error saving settings input state: %v", err)
+			return
+		}
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(p.T("🔙 Назад"), "settings:back"),
+			),
+		)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, p.HTML(prompt))
+		edit.ParseMode = render.ParseMode
+		edit.ReplyMarkup = &keyboard
+		bot.Send(edit)
+	}
+}
+
+// handleSettingsInput stores a value typed by an admin after picking a
+// setting. It reports whether the message was consumed.
+func handleSettingsInput(ctx context.Context, bot telegram.Client, states conversations.Store, chatsRepo *chats.Repository, msg *tgbotapi.Message) bool {
+	p := i18n.ForUser(ctx)
+	if msg.IsCommand() {
+		return false
+	}
+	key := conversations.Key{ChatID: msg.Chat.ID, UserID: msg.From.ID, Flow: settingsFlow}
+	var input settingsInput
+	exists, err := states.Load(ctx, key, &input)
+	if err != nil {
+		log.Printf("Error loading settings input state: %v", err)
+		return false
+	}
+	if !exists {
+		return false
+	}
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	switch input.Field {
+	case "topics":
+		topics := splitLines(msg.Text)
+		if len(topics) == 0 || len(topics) > maxTopics {
+			reply(p.T("❌ Нужно от 1 до %d тем. Попробуйте ещё раз:", maxTopics))
+			return true
+		}
+		for _, t := range topics {
+			if len(t) > 100 {
+				reply(p.T("❌ Тема слишком длинная. Максимум: 100 символов."))
+				return true
+			}
+		}
+		err = chatsRepo.SetTopics(ctx, msg.Chat.ID, topics)
+	case "durations":
+		settings := chatSettings(ctx, chatsRepo, msg.Chat.ID)
+		presets, def, perr := parseDurationPresets(msg.Text)
+		if perr != nil {
+			reply(p.HTML("❌ Неверный формат. Пример: <code>15m 30m* 1h 2h</code>"))
+			return true
+		}
+		for _, d := range presets {
+			if !settings.DurationAllowed(d) {
+				reply(p.T("❌ Длительность должна быть от %s до %s",
+					p.Duration(settings.MinDuration), p.Duration(settings.MaxDuration)))
+				return true
+			}
+		}
+		err = chatsRepo.SetDurations(ctx, msg.Chat.ID, presets, def)
+	case "limits":
+		fields := strings.Fields(msg.Text)
+		var minDur, maxDur time.Duration
+		var perr error
+		if len(fields) == 2 {
+			if minDur, perr = time.ParseDuration(fields[0]); perr == nil {
+				maxDur, perr = time.ParseDuration(fields[1])
+			}
+		}
+		if len(fields) != 2 || perr != nil || minDur < time.Minute || maxDur < minDur || maxDur > 30*24*time.Hour {
+			reply(p.HTML("❌ Неверный формат. Пример: <code>1m 168h</code> (не меньше минуты и не больше 720h)"))
+			return true
+		}
+		err = chatsRepo.SetDurationLimits(ctx, msg.Chat.ID, minDur, maxDur)
+	case "timezone":
+		name := strings.TrimSpace(msg.Text)
+		loc, lerr := time.LoadLocation(name)
+		if lerr != nil || name == "" || name == "Local" {
+			reply(p.HTML("❌ Неизвестный часовой пояс. Используйте формат IANA, например <code>Europe/Moscow</code>"))
+			return true
+		}
+		err = chatsRepo.SetTimezone(ctx, msg.Chat.ID, loc)
+	case "options":
+		options := splitLines(msg.Text)
+		// Telegram limits poll options to 100 characters
+		if len(options) != 2 || len([]rune(options[0])) > 100 || len([]rune(options[1])) > 100 {
+			reply(p.T("❌ Нужно ровно два варианта, каждый на своей строке. Попробуйте ещё раз:"))
+			return true
+		}
+		err = chatsRepo.SetOptionLabels(ctx, msg.Chat.ID, options[0], options[1])
+	}
+	if err != nil {
+		log.Printf("Error saving chat settings: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return true
+	}
+
+	deleteSettingsInput(ctx, states, key)
+	showSettingsMenu(ctx, bot, chatsRepo, msg.Chat.ID, input.MessageID)
+	return true
+}
+
+func deleteSettingsInput(ctx context.Context, states conversations.Store, key conversations.Key) {
+	if err := states.Delete(ctx, key); err != nil {
+		log.Printf("Error deleting settings input state: %v", err)
+	}
+}
+
+func showSettingsMenu(ctx context.Context, bot telegram.Client, chatsRepo *chats.Repository, chatID int64, messageID int) {
+	text, keyboard := settingsMenu(i18n.ForUser(ctx), chatSettings(ctx, chatsRepo, chatID), chatLanguage(ctx, chatsRepo, chatID))
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = render.ParseMode
+	edit.ReplyMarkup = &keyboard
+	bot.Send(edit)
+}
+
+func settingsMenu(p *i18n.Printer, settings *chats.Settings, lang i18n.Lang) (string, tgbotapi.InlineKeyboardMarkup) {
+	presets := make([]string, len(settings.DurationPresets))
+	for i, d := range settings.DurationPresets {
+		presets[i] = p.Duration(d)
+		if d == settings.DefaultDuration {
+			presets[i] += " ⭐"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(p.HTML("⚙️ <b>Настройки чата</b>\n\n"))
+	sb.WriteString(p.HTML("📋 <b>Темы:</b> %s\n", render.HTML(render.Join(settings.Topics, ", "))))
+	sb.WriteString(p.HTML("⏰ <b>Длительности:</b> %s\n", strings.Join(presets, ", ")))
+	sb.WriteString(p.HTML("↔️ <b>Допустимо:</b> от %s до %s\n",
+		p.Duration(settings.MinDuration), p.Duration(settings.MaxDuration)))
+	sb.WriteString(p.HTML("🌍 <b>Часовой пояс:</b> %s\n", settings.Timezone))
+	coming, notComing := settings.OptionComing, settings.OptionNotComing
+	if coming == "" {
+		coming = p.T("Иду")
+	}
+	if notComing == "" {
+		notComing = p.T("Не иду")
+	}
+	sb.WriteString(p.HTML("🗳 <b>Варианты ответа:</b> «%s» / «%s»\n", coming, notComing))
+	sb.WriteString(p.HTML("🔀 <b>Порядок очереди:</b> %s\n", p.T(strategyNames[settings.LineupStrategy])))
+	sb.WriteString(p.HTML("🌐 <b>Язык чата:</b> %s", lang.Name()))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("📋 Темы"), "settings:topics"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("⏰ Длительности"), "settings:durations"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("↔️ Пределы"), "settings:limits"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🌍 Часовой пояс"), "settings:timezone"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🗳 Варианты ответа"), "settings:options"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔀 Порядок очереди"), "settings:strategy"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🌐 Язык"), "settings:language"),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✅ Готово"), "settings:close"),
+		),
+	)
+	return sb.String(), keyboard
+}
+
+// parseDurationPresets parses "15m 30m* 1h". The starred duration is the
+// default one, otherwise the first.
+func parseDurationPresets(s string) ([]time.Duration, time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 12 {
+		return nil, 0, fmt.Errorf("bad number of durations")
+	}
+	var presets []time.Duration
+	var def time.Duration
+	for _, f := range fields {
+		starred := strings.HasSuffix(f, "*")
+		d, err := time.ParseDuration(strings.TrimSuffix(f, "*"))
+		if err != nil {
+			return nil, 0, err
+		}
+		if starred {
+			def = d
+		}
+		presets = append(presets, d)
+	}
+	if def == 0 {
+		def = presets[0]
+	}
+	return presets, def, nil
+}
+
+// splitLines returns the non-empty trimmed lines of s.
+func splitLines(s string) []string {
+	var res []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/ordering"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// strategyNames are the human readable names of lineup strategies,
+// translated where shown
+var strategyNames = map[string]string{
+	ordering.StrategyUniform: "🎲 случайный порядок",
+	ordering.StrategyFair:    "⚖️ честный порядок (учитывает прошлые очереди)",
+}
+
+// handleStrategyCommand handles "/strategy [uniform|fair]". Without arguments
+// it shows the current strategy; changing it is restricted to chat admins.
+func handleStrategyCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(msg.CommandArguments()))
+	if name == "" {
+		current, err := chatsRepo.GetLineupStrategy(ctx, msg.Chat.ID)
+		if err != nil {
+			log.Printf("Error getting lineup strategy: %v", err)
+			return
+		}
+		reply(p.HTML("🔀 <b>Порядок очереди:</b> %s\n\nИзменить: <code>/strategy uniform</code> или <code>/strategy fair</code>", p.T(strategyNames[current])))
+		return
+	}
+
+	if !ordering.IsKnown(name) {
+		reply(p.HTML("❌ Неизвестный порядок. Доступны: <code>uniform</code>, <code>fair</code>"))
+		return
+	}
+	if !perms.IsAdmin(ctx, msg.Chat.ID, msg.From.ID) {
+		reply(p.T("⛔ Менять порядок очереди могут только администраторы чата."))
+		return
+	}
+	if err := chatsRepo.SetLineupStrategy(ctx, msg.Chat.ID, name); err != nil {
+		log.Printf("Error saving lineup strategy: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	reply(p.HTML("✅ <b>Порядок очереди:</b> %s", p.T(strategyNames[name])))
+}
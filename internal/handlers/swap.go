@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/lineup"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// swapOfferTTL is how long the other participant has to answer a swap offer
+const swapOfferTTL = 10 * time.Minute
+
+// handleSwapCommand handles "/swap @user". The lineup is taken from the replied
+// results message or, if there is none, from the latest finished poll in the chat.
+func handleSwapCommand(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, msg *tgbotapi.Message, pollsService polls.Service) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	var (
+		poll *polls.TelegramPollDTO
+		err  error
+	)
+	if msg.ReplyToMessage != nil {
+		poll, err = pollsRepo.FindPollByResultsMessage(ctx, msg.Chat.ID, msg.ReplyToMessage.MessageID)
+	} else {
+		poll, err = pollsRepo.FindLatestProcessedPoll(ctx, msg.Chat.ID)
+	}
+	if err != nil {
+		log.Printf("Error finding poll for swap: %v", err)
+		reply(p.T("❌ Не найдена очередь. Ответьте командой на сообщение с результатами опроса."))
+		return
+	}
+
+	entries, err := votersRepo.GetQueue(ctx, poll.PollID)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		return
+	}
+
+	// Resolve the other participant from a text mention or an @username
+	var toUserID int64
+	for _, e := range msg.Entities {
+		if e.Type == "text_mention" && e.User != nil {
+			toUserID = e.User.ID
+			break
+		}
+	}
+	if toUserID == 0 {
+		username := strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "@")
+		for _, e := range entries {
+			if username != "" && strings.EqualFold(e.Username, username) {
+				toUserID = e.UserID
+				break
+			}
+		}
+	}
+	if toUserID == 0 {
+		reply(p.HTML("💡 Использование: <code>/swap @username</code>\n\nУчастник должен стоять в очереди."))
+		return
+	}
+
+	if refusal := offerSwap(ctx, bot, votersRepo, pollsService, poll.PollID, msg.Chat.ID, msg.From, toUserID, 0); refusal != "" {
+		reply(refusal)
+	}
+}
+
+// handleSwapStart shows the participants the presser can offer a swap to,
+// lineup.PageSize at a time. "swap_start:<poll>" posts the picker;
+// "swap_start:<poll>:<page>" comes from its "◀ / ▶" buttons and turns the page.
+func handleSwapStart(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return
+	}
+	pollID := parts[1]
+	page := 0
+	if len(parts) == 3 {
+		var err error
+		if page, err = strconv.Atoi(parts[2]); err != nil {
+			return
+		}
+	}
+	if !pollInChat(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Опрос не найден")))
+		return
+	}
+
+	entries, err := votersRepo.GetQueue(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ Ошибка. Попробуйте позже.")))
+		return
+	}
+
+	var waiting []voters.QueueEntryDTO
+	for _, e := range entries {
+		if e.Status == voters.QueueStatusWaiting {
+			waiting = append(waiting, e)
+		}
+	}
+	if len(waiting) < 2 {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("❌ В очереди некому меняться")))
+		return
+	}
+
+	// Telegram caps the number of buttons in a keyboard, so long queues are
+	// split into pages like the lineup itself
+	pages := lineup.Pages(len(waiting))
+	page = max(0, min(page, pages-1))
+	first := page * lineup.PageSize
+	last := min(first+lineup.PageSize, len(waiting))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, e := range waiting[first:last] {
+		label := fmt.Sprintf("%d. %s", e.Position, voterLabel(p, e.TelegramVoterDTO))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("swap_pick:%s:%d", pollID, e.UserID)),
+		))
+	}
+	if pages > 1 {
+		prev := (page + pages - 1) % pages
+		next := (page + 1) % pages
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀", fmt.Sprintf("swap_start:%s:%d", pollID, prev)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d / %d", page+1, pages), fmt.Sprintf("swap_start:%s:%d", pollID, page)),
+			tgbotapi.NewInlineKeyboardButtonData("▶", fmt.Sprintf("swap_start:%s:%d", pollID, next)),
+		))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	text := p.HTML("🔁 <b>Обмен местами</b>\n\nВыберите, с кем хотите поменяться:")
+
+	if len(parts) == 3 {
+		edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+		edit.ParseMode = render.ParseMode
+		edit.ReplyMarkup = &keyboard
+		bot.Send(edit)
+	} else {
+		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, text)
+		msg.ParseMode = render.ParseMode
+		msg.ReplyToMessageID = callback.Message.MessageID
+		msg.ReplyMarkup = keyboard
+		bot.Send(msg)
+	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+}
+
+// handleSwapPick turns the picker message into a swap offer from the presser.
+func handleSwapPick(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, callback *tgbotapi.CallbackQuery, data string, pollsService polls.Service) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 {
+		return
+	}
+	pollID := parts[1]
+	toUserID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+	if !pollInChat(ctx, pollsRepo, pollID, callback.Message.Chat.ID) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, i18n.ForUser(ctx).T("❌ Опрос не найден")))
+		return
+	}
+
+	refusal := offerSwap(ctx, bot, votersRepo, pollsService, pollID, callback.Message.Chat.ID, callback.From, toUserID, callback.Message.MessageID)
+	bot.Request(tgbotapi.NewCallback(callback.ID, refusal))
+}
+
+// offerSwap validates the pair and posts an accept/decline prompt for the other
+// participant. The prompt replaces promptMessageID if it is set. It returns a
+// refusal text for the initiator when no offer was made.
+func offerSwap(ctx context.Context, bot telegram.Client, votersRepo *voters.Repository, pollsService polls.Service, pollID string, chatID int64, from *tgbotapi.User, toUserID int64, promptMessageID int) string {
+	p := i18n.ForUser(ctx)
+	if from.ID == toUserID {
+		return p.T("❌ Нельзя поменяться местами с самим собой")
+	}
+
+	entries, err := votersRepo.GetQueue(ctx, pollID)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		return p.T("❌ Ошибка. Попробуйте позже.")
+	}
+	var fromEntry, toEntry *voters.QueueEntryDTO
+	for i := range entries {
+		switch entries[i].UserID {
+		case from.ID:
+			fromEntry = &entries[i]
+		case toUserID:
+			toEntry = &entries[i]
+		}
+	}
+	if fromEntry == nil || fromEntry.Status != voters.QueueStatusWaiting {
+		return p.T("❌ Вы не ждёте своей очереди")
+	}
+	if toEntry == nil || toEntry.Status != voters.QueueStatusWaiting {
+		return p.T("❌ Этот участник не ждёт своей очереди")
+	}
+
+	offer := &voters.SwapOfferDTO{
+		PollID:     pollID,
+		ChatID:     chatID,
+		FromUserID: from.ID,
+		ToUserID:   toUserID,
+		ExpiresAt:  time.Now().UTC().Add(swapOfferTTL),
+	}
+	offerID, err := votersRepo.CreateSwapOffer(ctx, offer)
+	if err != nil {
+		log.Printf("Error creating swap offer: %v", err)
+		return p.T("❌ Ошибка. Попробуйте позже.")
+	}
+
+	// The offer is for the other participant, so it speaks the chat's language
+	cp := i18n.ForChat(ctx)
+	text := cp.HTML("🔁 <b>Предложение обмена</b>\n\n%s, %s предлагает поменяться местами: %d ↔ %d.\n\n⌛ Предложение действует %s.",
+		lineup.Mention(cp, toEntry.TelegramVoterDTO),
+		lineup.Mention(cp, fromEntry.TelegramVoterDTO),
+		fromEntry.Position,
+		toEntry.Position,
+		cp.Duration(swapOfferTTL))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(cp.T("✅ Принять"), fmt.Sprintf("swap_accept:%d", offerID)),
+			tgbotapi.NewInlineKeyboardButtonData(cp.T("❌ Отклонить"), fmt.Sprintf("swap_decline:%d", offerID)),
+		),
+	)
+
+	messageID := promptMessageID
+	if promptMessageID == 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = render.ParseMode
+		msg.ReplyMarkup = keyboard
+		sent, err := bot.Send(msg)
+		if err != nil {
+			log.Printf("Error sending swap offer: %v", err)
+			return p.T("❌ Ошибка. Попробуйте позже.")
+		}
+		messageID = sent.MessageID
+	} else {
+		edit := tgbotapi.NewEditMessageText(chatID, promptMessageID, text)
+		edit.ParseMode = render.ParseMode
+		edit.ReplyMarkup = &keyboard
+		bot.Send(edit)
+	}
+
+	if err := votersRepo.SetSwapOfferMessage(ctx, offerID, messageID); err != nil {
+		log.Printf("Error saving swap offer message: %v", err)
+	}
+	if pollsService != nil {
+		if err := pollsService.ScheduleSwapOfferExpiry(ctx, polls.ExpireSwapOfferArgs{OfferID: offerID}, offer.ExpiresAt); err != nil {
+			log.Printf("enqueue swap offer expiry error: %v", err)
+		}
+	}
+	return ""
+}
+
+func handleSwapAccept(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, chatsRepo *chats.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	offerID, ok := parseSwapOfferID(data)
+	if !ok {
+		return
+	}
+	offer, err := votersRepo.GetSwapOffer(ctx, offerID)
+	if err != nil {
+		log.Printf("Error getting swap offer: %v", err)
+		return
+	}
+	if callback.From.ID != offer.ToUserID {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("⛔ Это предложение адресовано не вам")))
+		return
+	}
+
+	offer, err = votersRepo.AcceptSwapOffer(ctx, offerID)
+	switch {
+	case errors.Is(err, voters.ErrSwapOfferClosed):
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("⌛ Предложение уже неактуально")))
+		return
+	case errors.Is(err, voters.ErrSwapNotPossible):
+		_ = votersRepo.CloseSwapOffer(ctx, offerID, voters.SwapStatusDeclined)
+		closeSwapPrompt(bot, callback.Message, i18n.ForChat(ctx).T("❌ Обмен невозможен: очередь изменилась"))
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		return
+	case err != nil:
+		log.Printf("Error accepting swap offer: %v", err)
+		return
+	}
+
+	closeSwapPrompt(bot, callback.Message, i18n.ForChat(ctx).T("✅ Обмен местами состоялся"))
+
+	// Re-render the lineup with the new order
+	poll, err := pollsRepo.GetPoll(ctx, offer.PollID)
+	if err != nil {
+		log.Printf("Error getting poll: %v", err)
+	} else if poll.ResultsMessageID != 0 {
+		updateQueueMessage(ctx, bot, pollsRepo, votersRepo, chatsRepo, poll.ChatID, poll.ResultsMessageID, poll.PollID, lineup.CurrentPage)
+	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, p.T("🔁 Вы поменялись местами")))
+}
+
+func handleSwapDecline(ctx context.Context, bot telegram.Client, votersRepo *voters.Repository, callback *tgbotapi.CallbackQuery, data string) {
+	p := i18n.ForUser(ctx)
+	offerID, ok := parseSwapOfferID(data)
+	if !ok {
+		return
+	}
+	offer, err := votersRepo.GetSwapOffer(ctx, offerID)
+	if err != nil {
+		log.Printf("Error getting swap offer: %v", err)
+		return
+	}
+	// The initiator may withdraw the offer as well
+	if callback.From.ID != offer.ToUserID && callback.From.ID != offer.FromUserID {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("⛔ Это предложение адресовано не вам")))
+		return
+	}
+
+	err = votersRepo.CloseSwapOffer(ctx, offerID, voters.SwapStatusDeclined)
+	if errors.Is(err, voters.ErrSwapOfferClosed) {
+		bot.Request(tgbotapi.NewCallback(callback.ID, p.T("⌛ Предложение уже неактуально")))
+		return
+	}
+	if err != nil {
+		log.Printf("Error declining swap offer: %v", err)
+		return
+	}
+
+	closeSwapPrompt(bot, callback.Message, i18n.ForChat(ctx).T("❌ Обмен отклонён"))
+	bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+}
+
+func closeSwapPrompt(bot telegram.Client, message *tgbotapi.Message, text string) {
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, text)
+	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	bot.Send(edit)
+}
+
+func parseSwapOfferID(data string) (int64, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// voterLabel renders a voter as plain text for button labels.
+func voterLabel(p *i18n.Printer, v voters.TelegramVoterDTO) string {
+	if v.Username != "" {
+		return "@" + v.Username
+	}
+	if v.Name != "" {
+		return v.Name
+	}
+	return p.T("Аноним")
+}
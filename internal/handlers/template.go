@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/lineup"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// templateHelp lists what a queue line template can use
+const templateHelp = `Шаблон задаёт одну строку очереди (Go <code>text/template</code>, разметка HTML). Доступно:
+• <code>{{.Position}}</code> — номер в очереди
+• <code>{{.Mention}}</code> — @username (Имя) или имя со ссылкой на профиль
+• <code>{{.Name}}</code>, <code>{{.Username}}</code>, <code>{{.UserID}}</code> — имя, username и ID
+• <code>{{.Status}}</code> — waiting, current, done или skipped
+• <code>{{.Time}}</code> — ожидаемое время выступления, может быть пустым
+• <code>{{.Waitlist}}</code> — участник в листе ожидания
+
+Изменить: <code>/template</code> и шаблон с новой строки
+Проверить без сохранения: <code>/template preview</code> и шаблон
+Вернуть стандартный: <code>/template reset</code>`
+
+// handleTemplateCommand handles "/template [preview|reset] [template]": it
+// shows, previews and changes the chat's lineup line template. The route
+// lets only chat admins through.
+func handleTemplateCommand(ctx context.Context, bot telegram.Client, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) bool {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		_, err := bot.Send(r)
+		return err == nil
+	}
+	loc := chatLocation(ctx, chatsRepo, msg.Chat.ID)
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	action, rest := args, ""
+	if i := strings.IndexFunc(args, unicode.IsSpace); i >= 0 {
+		action, rest = args[:i], args[i:]
+	}
+	switch strings.ToLower(action) {
+	case "":
+		current, err := chatsRepo.GetLineupTemplate(ctx, msg.Chat.ID)
+		if err != nil {
+			log.Printf("Error getting lineup template: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		name := p.T("свой")
+		if current == "" {
+			name, current = p.T("стандартный"), lineup.DefaultTemplate
+		}
+		reply(p.HTML("🧩 <b>Шаблон очереди</b> (%s):\n<pre>%s</pre>\n\n", name, current) + p.HTML(templateHelp))
+		previewTemplate(p, reply, lineup.ForChat(ctx, chatsRepo, msg.Chat.ID), loc)
+		return
+	case "reset":
+		if err := chatsRepo.SetLineupTemplate(ctx, msg.Chat.ID, ""); err != nil {
+			log.Printf("Error saving lineup template: %v", err)
+			reply(p.T("❌ Ошибка. Попробуйте позже."))
+			return
+		}
+		reply(p.T("✅ Восстановлен стандартный шаблон очереди."))
+		return
+	case "preview":
+		args = strings.TrimSpace(rest)
+		if args == "" {
+			reply(p.HTML(templateHelp))
+			return
+		}
+	}
+
+	r, err := lineup.New(args)
+	if err != nil {
+		reply(p.HTML("❌ Ошибка в шаблоне: %s", err))
+		return
+	}
+	// Telegram rejects broken markup; a template is saved only once its
+	// preview went through
+	if !previewTemplate(p, reply, r, loc) {
+		reply(p.T("❌ Telegram не принял разметку шаблона. Проверьте, что все теги закрыты."))
+		return
+	}
+	if strings.EqualFold(action, "preview") {
+		return
+	}
+	if err := chatsRepo.SetLineupTemplate(ctx, msg.Chat.ID, args); err != nil {
+		log.Printf("Error saving lineup template: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	reply(p.T("✅ Шаблон очереди сохранён."))
+}
+
+// previewTemplate sends the sample lineup drawn with r and reports whether
+// Telegram accepted it.
+func previewTemplate(p *i18n.Printer, reply func(text string) bool, r *lineup.Renderer, loc *time.Location) bool {
+	text, err := r.Preview(p, loc)
+	if err != nil {
+		log.Printf("Error rendering lineup preview: %v", err)
+		return false
+	}
+	return reply(p.HTML("👀 <b>Предпросмотр</b>\n\n") + text)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/permissions"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// handleTimezoneCommand handles "/timezone [Area/City]". Without arguments
+// it shows the current timezone; changing it is restricted to chat admins.
+func handleTimezoneCommand(ctx context.Context, bot telegram.Client, perms *permissions.Checker, chatsRepo *chats.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" {
+		loc := chatLocation(ctx, chatsRepo, msg.Chat.ID)
+		reply(p.HTML("🌍 <b>Часовой пояс:</b> %s (сейчас %s)\n\nИзменить: <code>/timezone Europe/Moscow</code>",
+			loc.String(), time.Now().In(loc).Format("15:04")))
+		return
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		reply(p.HTML("❌ Неизвестный часовой пояс. Используйте формат IANA, например <code>Europe/Moscow</code>"))
+		return
+	}
+	if !perms.IsAdmin(ctx, msg.Chat.ID, msg.From.ID) {
+		reply(p.T("⛔ Менять часовой пояс могут только администраторы чата."))
+		return
+	}
+	if err := chatsRepo.SetTimezone(ctx, msg.Chat.ID, loc); err != nil {
+		log.Printf("Error saving timezone: %v", err)
+		reply(p.T("❌ Ошибка. Попробуйте позже."))
+		return
+	}
+	reply(p.HTML("✅ <b>Часовой пояс:</b> %s", loc.String()))
+}
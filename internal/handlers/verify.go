@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/ordering"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// handleVerifyCommand handles "/verify [poll_id]". It recomputes the published
+// lineup from the revealed secret and the stored input and reports whether it
+// matches. Without an argument the replied results message or the latest
+// finished poll in the chat is checked.
+func handleVerifyCommand(ctx context.Context, bot telegram.Client, pollsRepo *polls.Repository, votersRepo *voters.Repository, msg *tgbotapi.Message) {
+	p := i18n.ForUser(ctx)
+	reply := func(text string) {
+		r := tgbotapi.NewMessage(msg.Chat.ID, text)
+		r.ParseMode = render.ParseMode
+		r.ReplyToMessageID = msg.MessageID
+		bot.Send(r)
+	}
+
+	var (
+		poll *polls.TelegramPollDTO
+		err  error
+	)
+	switch pollID := strings.TrimSpace(msg.CommandArguments()); {
+	case pollID != "":
+		poll, err = pollsRepo.GetPoll(ctx, pollID)
+		if err == nil && poll.ChatID != msg.Chat.ID {
+			err = fmt.Errorf("poll %s belongs to another chat", pollID)
+		}
+	case msg.ReplyToMessage != nil:
+		poll, err = pollsRepo.FindPollByResultsMessage(ctx, msg.Chat.ID, msg.ReplyToMessage.MessageID)
+	default:
+		poll, err = pollsRepo.FindLatestProcessedPoll(ctx, msg.Chat.ID)
+	}
+	if err != nil {
+		log.Printf("Error finding poll for verify: %v", err)
+		reply(p.HTML("❌ Опрос не найден. Использование: <code>/verify ID_опроса</code>"))
+		return
+	}
+
+	result, err := votersRepo.GetPollResult(ctx, poll.PollID)
+	if err != nil || result.SeedSecret == "" {
+		reply(p.T("❌ Для этого опроса нет данных жеребьёвки."))
+		return
+	}
+	_, commitment, err := pollsRepo.GetSeedSecret(ctx, poll.PollID)
+	if err != nil {
+		log.Printf("Error getting seed commitment: %v", err)
+		return
+	}
+
+	reply(drawReport(p, poll, result, commitment))
+}
+
+// drawReport checks a draw against its announced commitment: the secret, the
+// seed derived from it and the stored input, and the lineup recomputed from
+// the seed. Any change to the stored data after the draw fails one of them.
+func drawReport(p *i18n.Printer, poll *polls.TelegramPollDTO, result *voters.PollResultDTO, commitment string) string {
+	var sb strings.Builder
+	sb.WriteString(p.HTML("🔍 <b>Проверка жеребьёвки</b>\n\n📋 <b>Тема:</b> %s\n", poll.Topic))
+	ok := true
+	switch {
+	case commitment == "":
+		sb.WriteString(p.T("⚠️ Хэш секрета не объявлялся при создании опроса\n"))
+	case ordering.Commit(result.SeedSecret) == commitment:
+		sb.WriteString(p.HTML("✅ Секрет совпадает с объявленным хэшем <code>%s</code>\n", commitment))
+	default:
+		ok = false
+		sb.WriteString(p.T("❌ Секрет не совпадает с объявленным хэшем\n"))
+	}
+	switch result.Seed {
+	case ordering.Seed(result.SeedSecret, poll.PollID, result.InputUserIDs, result.Weights):
+		sb.WriteString(p.HTML("✅ Сид <code>%d</code> получен из секрета, ID опроса и состава жеребьёвки\n", result.Seed))
+	case ordering.LegacySeed(result.SeedSecret, poll.PollID):
+		// Drawn before the input was part of the seed
+		sb.WriteString(p.HTML("⚠️ Сид <code>%d</code> получен по старой схеме: состав и веса жеребьёвки им не закреплены\n", result.Seed))
+	default:
+		ok = false
+		sb.WriteString(p.T("❌ Сид не соответствует секрету\n"))
+	}
+
+	vs := make([]voters.TelegramVoterDTO, len(result.InputUserIDs))
+	for i, id := range result.InputUserIDs {
+		vs[i] = voters.TelegramVoterDTO{UserID: id}
+	}
+	if result.Weights != nil && len(result.Weights) != len(vs) {
+		ok = false
+		sb.WriteString(p.T("❌ Пересчитанная очередь не совпадает с опубликованной\n"))
+	} else {
+		ordering.Arrange(rand.New(rand.NewSource(result.Seed)), vs, result.Weights)
+		recomputed := make([]int64, len(vs))
+		for i, v := range vs {
+			recomputed[i] = v.UserID
+		}
+		if slices.Equal(recomputed, result.LineupUserIDs) {
+			sb.WriteString(p.HTML("✅ Пересчитанная очередь совпадает: %s, порядок: %s\n", p.Count(len(vs), i18n.Participants), result.Strategy))
+		} else {
+			ok = false
+			sb.WriteString(p.T("❌ Пересчитанная очередь не совпадает с опубликованной\n"))
+		}
+	}
+
+	if ok {
+		sb.WriteString(p.HTML("\n🎉 <b>Очередь честная</b>"))
+	} else {
+		sb.WriteString(p.HTML("\n⚠️ <b>Проверка не пройдена</b>"))
+	}
+	return sb.String()
+}
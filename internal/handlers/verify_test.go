@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/ordering"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// draw returns an honest fair draw of a poll and the commitment announced
+// for it, the way the finish job makes them.
+func draw(pollID string) (*voters.PollResultDTO, string) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	r := &voters.PollResultDTO{
+		PollID:       pollID,
+		SeedSecret:   secret,
+		Strategy:     ordering.StrategyFair,
+		InputUserIDs: []int64{11, 22, 33, 44, 55},
+		Weights:      []float64{1, 3, 2, 1.5, 2},
+	}
+	r.Seed = ordering.Seed(secret, pollID, r.InputUserIDs, r.Weights)
+	vs := make([]voters.TelegramVoterDTO, len(r.InputUserIDs))
+	for i, id := range r.InputUserIDs {
+		vs[i] = voters.TelegramVoterDTO{UserID: id}
+	}
+	ordering.Arrange(rand.New(rand.NewSource(r.Seed)), vs, r.Weights)
+	for _, v := range vs {
+		r.LineupUserIDs = append(r.LineupUserIDs, v.UserID)
+	}
+	return r, ordering.Commit(secret)
+}
+
+func TestDrawReport(t *testing.T) {
+	poll := &polls.TelegramPollDTO{PollID: "5432", Topic: "Разбор <задач>"}
+	const (
+		fair   = "🎉 <b>Очередь честная</b>"
+		failed = "⚠️ <b>Проверка не пройдена</b>"
+	)
+	tests := []struct {
+		name   string
+		tamper func(r *voters.PollResultDTO, commitment *string)
+		want   string
+	}{
+		{"honest", func(*voters.PollResultDTO, *string) {}, fair},
+		{"no commitment", func(_ *voters.PollResultDTO, c *string) { *c = "" }, fair},
+		{"other secret", func(r *voters.PollResultDTO, _ *string) { r.SeedSecret = "fedcba9876543210fedcba9876543210" }, failed},
+		{"other commitment", func(_ *voters.PollResultDTO, c *string) { *c = ordering.Commit("other") }, failed},
+		{"seed changed", func(r *voters.PollResultDTO, _ *string) { r.Seed++ }, failed},
+		{"weight raised", func(r *voters.PollResultDTO, _ *string) { r.Weights[0] = 3 }, failed},
+		{"weights dropped", func(r *voters.PollResultDTO, _ *string) { r.Weights = nil }, failed},
+		{"weights cut short", func(r *voters.PollResultDTO, _ *string) { r.Weights = r.Weights[:2] }, failed},
+		{"voter added", func(r *voters.PollResultDTO, _ *string) { r.InputUserIDs = append(r.InputUserIDs, 66) }, failed},
+		{"lineup reordered", func(r *voters.PollResultDTO, _ *string) {
+			r.LineupUserIDs[0], r.LineupUserIDs[1] = r.LineupUserIDs[1], r.LineupUserIDs[0]
+		}, failed},
+		{"weights and seed rewritten", func(r *voters.PollResultDTO, _ *string) {
+			// Consistent with itself, but the seed no longer comes from the
+			// secret announced with the original input
+			r.Weights[0] = 3
+			r.Seed = ordering.Seed("forged", r.PollID, r.InputUserIDs, r.Weights)
+		}, failed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, commitment := draw(poll.PollID)
+			tt.tamper(r, &commitment)
+			got := drawReport(i18n.For(i18n.Russian), poll, r, commitment)
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("report ends with %q, want %q:\n%s", got[strings.LastIndex(got, "\n")+1:], tt.want, got)
+			}
+			if !strings.Contains(got, "Разбор &lt;задач&gt;") {
+				t.Errorf("topic not escaped:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestDrawReportLegacySeed(t *testing.T) {
+	poll := &polls.TelegramPollDTO{PollID: "5432", Topic: "Old"}
+	r, commitment := draw(poll.PollID)
+	r.Seed = ordering.LegacySeed(r.SeedSecret, poll.PollID)
+	vs := make([]voters.TelegramVoterDTO, len(r.InputUserIDs))
+	for i, id := range r.InputUserIDs {
+		vs[i] = voters.TelegramVoterDTO{UserID: id}
+	}
+	ordering.Arrange(rand.New(rand.NewSource(r.Seed)), vs, r.Weights)
+	for i, v := range vs {
+		r.LineupUserIDs[i] = v.UserID
+	}
+
+	got := drawReport(i18n.For(i18n.English), poll, r, commitment)
+	if !strings.Contains(got, "uses the old scheme") {
+		t.Errorf("legacy seed not reported:\n%s", got)
+	}
+}
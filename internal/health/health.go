@@ -0,0 +1,91 @@
+// Package health serves the liveness and readiness probes of the service and
+// worker processes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CheckTimeout bounds a single readiness probe, so a hung dependency fails it
+// instead of stalling the orchestrator.
+const CheckTimeout = 3 * time.Second
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+// Report is the JSON body of a probe response.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Live answers liveness probes: the process is up and serving HTTP.
+func Live(w http.ResponseWriter, _ *http.Request) {
+	write(w, http.StatusOK, Report{Status: "ok"})
+}
+
+// Ready returns a handler that runs every check and responds 200 when all
+// pass or 503 with the failing errors otherwise.
+func Ready(checks map[string]Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+		defer cancel()
+
+		report := Report{Status: "ok", Checks: make(map[string]string, len(checks))}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := "ok"
+				if err := check(ctx); err != nil {
+					result = err.Error()
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				report.Checks[name] = result
+				if result != "ok" {
+					report.Status = "unavailable"
+				}
+			}()
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		if report.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		write(w, code, report)
+	}
+}
+
+// Cached wraps check so its result is reused for ttl. Probes hit readiness
+// often; this keeps them from spending the Bot API quota.
+func Cached(check Check, ttl time.Duration) Check {
+	var (
+		mu      sync.Mutex
+		err     error
+		checked time.Time
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return err
+		}
+		err = check(ctx)
+		checked = time.Now()
+		return err
+	}
+}
+
+func write(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
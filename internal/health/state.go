@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Phase is a stage in the lifecycle of a long-running component.
+type Phase int32
+
+const (
+	Starting Phase = iota
+	Running
+	Stopping
+	Stopped
+)
+
+func (p Phase) String() string {
+	switch p {
+	case Starting:
+		return "starting"
+	case Running:
+		return "running"
+	case Stopping:
+		return "stopping"
+	case Stopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("phase(%d)", int32(p))
+	}
+}
+
+// State tracks the phase of a component, such as the River client, so
+// probes can report it. The zero value is Starting.
+type State struct {
+	phase atomic.Int32
+}
+
+// Set moves the component to phase.
+func (s *State) Set(phase Phase) {
+	s.phase.Store(int32(phase))
+}
+
+// Phase returns the current phase.
+func (s *State) Phase() Phase {
+	return Phase(s.phase.Load())
+}
+
+// Check passes only while the component is running.
+func (s *State) Check(context.Context) error {
+	if phase := s.Phase(); phase != Running {
+		return errors.New(phase.String())
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package i18n
+
+// english translates the Russian source texts into English
+var english = map[string]string{
+	// Common
+	"❌ Ошибка. Попробуйте позже.": "❌ Something went wrong. Please try again later.",
+	"❌ Отмена": "❌ Cancel",
+	"🔙 Назад":  "🔙 Back",
+	"✅ Готово": "✅ Done",
+	"Аноним":   "Anonymous",
+	"Опрос":    "Poll",
+	"Иду":      "Coming",
+	"Не иду":   "Not coming",
+	"⏳ Слишком часто, подождите пару секунд":                                                   "⏳ Too fast, please wait a couple of seconds",
+	"⛔ Настройки доступны только администраторам чата.":                                        "⛔ Only chat admins can open the settings.",
+	"⛔ Создавать опросы в этом чате вам нельзя.":                                               "⛔ You cannot create polls in this chat.",
+	"⛔ Управлять опросом и очередью могут только автор опроса, ведущие и администраторы чата.": "⛔ Only the poll author, hosts and chat admins can manage the poll and the queue.",
+
+	// Command menu
+	"Создать опрос":                "Create a poll",
+	"Поменяться местами в очереди": "Swap places in the queue",
+	"Проверить жеребьёвку":         "Verify the draw",
+	"Завершить опрос":              "Close the poll",
+	"Продлить опрос":               "Extend the poll",
+	"Отменить опрос":               "Cancel the poll",
+	"Регулярные опросы":            "Recurring polls",
+	"Часовой пояс чата":            "Chat timezone",
+	"Язык чата":                    "Chat language",
+	"Порядок очереди":              "Queue order",
+	"Роли участников":              "Member roles",
+	"Шаблон строки очереди":        "Queue line template",
+	"Настройки чата":               "Chat settings",
+
+	// Poll
+	"📋 Тема: %s\n⏰ Длительность: %s\n🕐 Завершится: %s": "📋 Topic: %s\n⏰ Duration: %s\n🕐 Ends: %s",
+	"\n👥 Мест: %d": "\n👥 Places: %d",
+	"⏹ Завершить":  "⏹ Close",
+	"➕ 30 мин":     "➕ 30 min",
+	"🗑 Отменить":   "🗑 Cancel",
+	"🔐 Хэш секрета жеребьёвки: <code>%s</code>\nСекрет будет раскрыт вместе с результатами.": "🔐 Draw secret hash: <code>%s</code>\nThe secret will be revealed with the results.",
+
+	// Poll wizard
+	"📝 <b>Создание опроса</b>\n\nВведите тему опроса:":                                                                                           "📝 <b>New poll</b>\n\nEnter the poll topic:",
+	"📝 <b>Создание опроса</b>\n\nВыберите тему опроса или введите свою:":                                                                         "📝 <b>New poll</b>\n\nPick a topic or enter your own:",
+	"📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s":                                                                                              "📝 <b>New poll</b>\n\n✅ <b>Topic:</b> %s",
+	"📝 <b>Создание опроса</b>\n\n✅ <b>Тема:</b> %s\n⏰ <b>Длительность:</b> %s\n":                                                                 "📝 <b>New poll</b>\n\n✅ <b>Topic:</b> %s\n⏰ <b>Duration:</b> %s\n",
+	"⏰ <b>Выбор длительности опроса</b>\n\n📋 <b>Тема:</b> %s\n\nВыберите длительность или введите свою:":                                         "⏰ <b>Poll duration</b>\n\n📋 <b>Topic:</b> %s\n\nPick a duration or enter your own:",
+	"✏️ <b>Ввод темы</b>\n\nВведите тему опроса:\n\nПример: <code>Базы данных</code>, <code>Стрельба из лука</code>, <code>Battlefield 6</code>": "✏️ <b>Topic</b>\n\nEnter the poll topic:\n\nFor example: <code>Databases</code>, <code>Archery</code>, <code>Battlefield 6</code>",
+	"✏️ <b>Ввод длительности</b>\n\n📋 <b>Тема:</b> %s\n\nВведите длительность в формате:\n• <code>30m</code> - минуты\n• <code>2h</code> - часы\n• <code>1h30m</code> - комбинированный формат\n• <code>24h</code> - сутки\n\nПример: <code>45m</code>, <code>2h30m</code>, <code>6h</code>": "✏️ <b>Duration</b>\n\n📋 <b>Topic:</b> %s\n\nEnter the duration as:\n• <code>30m</code> - minutes\n• <code>2h</code> - hours\n• <code>1h30m</code> - both\n• <code>24h</code> - a day\n\nFor example: <code>45m</code>, <code>2h30m</code>, <code>6h</code>",
+	"✏️ <b>Ввод числа мест</b>\n\n📋 <b>Тема:</b> %s\n\nВведите, сколько человек попадёт в основной список. Остальные окажутся в листе ожидания.\n\nПример: <code>12</code>, <code>25</code>":                                                                                                 "✏️ <b>Places</b>\n\n📋 <b>Topic:</b> %s\n\nEnter how many people get into the main list. Everyone else goes to the waitlist.\n\nFor example: <code>12</code>, <code>25</code>",
+	"🗓 <b>Время начала и слоты</b>\n\n📋 <b>Тема:</b> %s\n\nВведите время начала занятия и длительность выступления одного человека. Время указывается в часовом поясе чата (%s).\n\nПример: <code>18:30 10m</code>, <code>25.12 09:00 15m</code>":                                            "🗓 <b>Start time and slots</b>\n\n📋 <b>Topic:</b> %s\n\nEnter when the session starts and how long each person presents. The time is in the chat timezone (%s).\n\nFor example: <code>18:30 10m</code>, <code>25.12 09:00 15m</code>",
+	"✅ <b>Подтверждение опроса</b>\n\n📋 <b>Тема:</b> %s\n⏰ <b>Длительность:</b> %s\n👥 <b>Мест:</b> %s\n🗓 <b>Начало занятия:</b> %s\n\nВсё правильно?":                                                                                                                                        "✅ <b>Confirm the poll</b>\n\n📋 <b>Topic:</b> %s\n⏰ <b>Duration:</b> %s\n👥 <b>Places:</b> %s\n🗓 <b>Session start:</b> %s\n\nIs everything right?",
+	"не задано":                      "not set",
+	"%s, по %s на человека":          "%s, %s per person",
+	"без ограничений":                "unlimited",
+	"♾ Без лимита":                   "♾ No limit",
+	"✏️ Свое число мест":             "✏️ Custom places",
+	"✏️ Свое значение":               "✏️ Custom",
+	"🗓 Время начала и слоты":         "🗓 Start time and slots",
+	"🗑 Без расписания":               "🗑 No schedule",
+	"✅ Создать":                      "✅ Create",
+	"🔁 Повторить":                    "🔁 Retry",
+	"✅ <b>Опрос успешно создан!</b>": "✅ <b>Poll created!</b>",
+	"❌ Создание опроса отменено.":    "❌ Poll creation cancelled.",
+	"❌ Ошибка при создании опроса, опрос не был создан. Попробуйте ещё раз.":                                                           "❌ The poll could not be created. Please try again.",
+	"❌ Ошибка при создании опроса, опрос не был создан. Попробуйте позже.":                                                             "❌ The poll could not be created. Please try again later.",
+	"❌ Тема не может быть пустой. Попробуйте ещё раз:":                                                                                 "❌ The topic cannot be empty. Please try again:",
+	"❌ Тема слишком длинная. Максимум: 100 символов.":                                                                                  "❌ The topic is too long. The maximum is 100 characters.",
+	"❌ Длительность не может быть пустой. Попробуйте ещё раз:":                                                                         "❌ The duration cannot be empty. Please try again:",
+	"❌ Неверный формат длительности. Используйте формат: <code>30m</code>, <code>2h</code>, <code>1h30m</code>\n\nПопробуйте ещё раз:": "❌ Invalid duration. Use a format like <code>30m</code>, <code>2h</code>, <code>1h30m</code>\n\nPlease try again:",
+	"❌ Длительность слишком короткая. Минимум: %s":                                                                                     "❌ The duration is too short. The minimum is %s",
+	"❌ Длительность слишком большая. Максимум: %s":                                                                                     "❌ The duration is too long. The maximum is %s",
+	"❌ Длительность должна быть от %s до %s":                                                                                           "❌ The duration must be between %s and %s",
+	"❌ Неверный формат. Используйте: <code>18:30 10m</code> или <code>25.12 18:30 10m</code>\n\nПопробуйте ещё раз:":                   "❌ Invalid format. Use <code>18:30 10m</code> or <code>25.12 18:30 10m</code>\n\nPlease try again:",
+	"❌ Введите число от 1 до %d:":                                                                                                      "❌ Enter a number from 1 to %d:",
+	"💡 <b>Создание опроса</b>\n\nИспользуйте команду <code>/poll</code> без параметров для интерактивного создания опроса.\n\nИли используйте старый формат: <code>/poll Тема | 30m</code>, <code>/poll Тема | 30m | 20</code>, где 20 — число мест (0 — без ограничений), или <code>/poll Тема | 30m | 20 | 18:30 10m</code> с временем начала занятия и длительностью выступления": "💡 <b>New poll</b>\n\nSend <code>/poll</code> without arguments to create a poll step by step.\n\nOr use the one-line format: <code>/poll Topic | 30m</code>, <code>/poll Topic | 30m | 20</code>, where 20 is the number of places (0 means unlimited), or <code>/poll Topic | 30m | 20 | 18:30 10m</code> with the session start and the time per person",
+	"⛔ Создавать опросы в этом чате могут %s.":                                 "⛔ In this chat polls can be created by %s.",
+	"⛔ Это не ваше меню создания опроса или оно устарело. Начните своё: /poll": "⛔ This poll menu is not yours or has expired. Start your own: /poll",
+
+	// Poll control
+	"❌ Активный опрос не найден. Ответьте командой на сообщение с опросом.": "❌ No active poll found. Reply with the command to the poll message.",
+	"💡 Использование: <code>/extend 30m</code>":                             "💡 Usage: <code>/extend 30m</code>",
+	"❌ Опрос не найден":                               "❌ Poll not found",
+	"⏹ Опрос завершён, результаты скоро появятся.":    "⏹ The poll is closed, the results will follow shortly.",
+	"❌ Опрос не может длиться дольше %s":              "❌ A poll cannot last longer than %s",
+	"⏰ <b>Опрос продлён</b> до %s":                    "⏰ <b>Poll extended</b> until %s",
+	"🗑 Опрос отменён, очередь составляться не будет.": "🗑 The poll was cancelled, there will be no queue.",
+	"🗑 Опрос отменён.":                                "🗑 The poll was cancelled.",
+	"❌ Опрос уже завершён или отменён.":               "❌ The poll is already closed or cancelled.",
+
+	// Lineup
+	"🎯 <b>Результаты опроса:</b> %s\n\n":                                   "🎯 <b>Poll results:</b> %s\n\n",
+	"😔 <b>Никто не идет</b>\n\n":                                           "😔 <b>Nobody is coming</b>\n\n",
+	"💡 Используйте кнопки ниже, чтобы присоединиться к очереди!":           "💡 Use the buttons below to join the queue!",
+	"👥 <b>Участников:</b> %d (%s)\n\n":                                     "👥 <b>Participants:</b> %d (%s)\n\n",
+	"👥 <b>Участников:</b> %d\n\n":                                          "👥 <b>Participants:</b> %d\n\n",
+	"🗓 <b>Начало:</b> %s, %s на человека\n\n":                              "🗓 <b>Start:</b> %s, %s per person\n\n",
+	"🏆 <b>Очередь участников:</b>\n":                                       "🏆 <b>Queue:</b>\n",
+	"\n⏳ <b>Лист ожидания:</b>\n":                                          "\n⏳ <b>Waitlist:</b>\n",
+	"\n📄 Страница %d из %d\n":                                              "\n📄 Page %d of %d\n",
+	"\n🏁 <b>Все выступили</b>\n":                                           "\n🏁 <b>Everyone has presented</b>\n",
+	"\n🔐 <b>Жеребьёвка:</b> сид <code>%d</code>, секрет <code>%s</code>\n": "\n🔐 <b>Draw:</b> seed <code>%d</code>, secret <code>%s</code>\n",
+	"Проверить: <code>/verify %s</code>\n":                                 "Verify: <code>/verify %s</code>\n",
+	"\n💡 <b>Используйте кнопки ниже для управления очередью</b>":           "\n💡 <b>Use the buttons below to manage the queue</b>",
+	"Пример <темы> & шаблона":                                              "Sample <topic> & template",
+	"🙋 Войти":              "🙋 Join",
+	"🚪 Выйти":              "🚪 Leave",
+	"⏭ Пропустить":         "⏭ Skip",
+	"🔁 Поменяться местами": "🔁 Swap places",
+
+	// Queue
+	"🚪 Вы вышли из очереди":         "🚪 You left the queue",
+	"🙋 Вы присоединились к очереди": "🙋 You joined the queue",
+	"🏁 Очередь уже завершена":       "🏁 The queue is already over",
+	"🔔 Ваша очередь, %s!":           "🔔 Your turn, %s!",
+	"✅ Отмечено":                    "✅ Marked",
+	"⏭ Пропущено":                   "⏭ Skipped",
+	"🎉 %s, освободилось место — вы в основном списке под номером %d!": "🎉 %s, a place opened up — you are number %d in the main list!",
+
+	// Swaps
+	"❌ Не найдена очередь. Ответьте командой на сообщение с результатами опроса.":        "❌ No queue found. Reply with the command to the poll results message.",
+	"💡 Использование: <code>/swap @username</code>\n\nУчастник должен стоять в очереди.": "💡 Usage: <code>/swap @username</code>\n\nThe participant must be in the queue.",
+	"❌ В очереди некому меняться":                                  "❌ There is nobody to swap with",
+	"🔁 <b>Обмен местами</b>\n\nВыберите, с кем хотите поменяться:": "🔁 <b>Swap places</b>\n\nChoose who to swap with:",
+	"❌ Нельзя поменяться местами с самим собой":                    "❌ You cannot swap with yourself",
+	"❌ Вы не ждёте своей очереди":                                  "❌ You are not waiting in the queue",
+	"❌ Этот участник не ждёт своей очереди":                        "❌ This participant is not waiting in the queue",
+	"🔁 <b>Предложение обмена</b>\n\n%s, %s предлагает поменяться местами: %d ↔ %d.\n\n⌛ Предложение действует %s.": "🔁 <b>Swap offer</b>\n\n%s, %s offers to swap places: %d ↔ %d.\n\n⌛ The offer is valid for %s.",
+	"✅ Принять":   "✅ Accept",
+	"❌ Отклонить": "❌ Decline",
+	"❌ Обмен невозможен: очередь изменилась": "❌ The swap is no longer possible: the queue has changed",
+	"✅ Обмен местами состоялся":              "✅ Places swapped",
+	"🔁 Вы поменялись местами":                "🔁 You swapped places",
+	"⛔ Это предложение адресовано не вам":    "⛔ This offer is not for you",
+	"⌛ Предложение уже неактуально":          "⌛ The offer is no longer valid",
+	"❌ Обмен отклонён":                       "❌ Swap declined",
+	"⌛ Предложение обмена истекло":           "⌛ The swap offer has expired",
+
+	// Draw verification
+	"❌ Опрос не найден. Использование: <code>/verify ID_опроса</code>":                            "❌ Poll not found. Usage: <code>/verify POLL_ID</code>",
+	"❌ Для этого опроса нет данных жеребьёвки.":                                                   "❌ There is no draw data for this poll.",
+	"🔍 <b>Проверка жеребьёвки</b>\n\n📋 <b>Тема:</b> %s\n":                                         "🔍 <b>Draw verification</b>\n\n📋 <b>Topic:</b> %s\n",
+	"⚠️ Хэш секрета не объявлялся при создании опроса\n":                                          "⚠️ No secret hash was announced when the poll was created\n",
+	"✅ Секрет совпадает с объявленным хэшем <code>%s</code>\n":                                    "✅ The secret matches the announced hash <code>%s</code>\n",
+	"❌ Секрет не совпадает с объявленным хэшем\n":                                                 "❌ The secret does not match the announced hash\n",
+	"✅ Сид <code>%d</code> получен из секрета, ID опроса и состава жеребьёвки\n":                  "✅ Seed <code>%d</code> is derived from the secret, the poll ID and the draw input\n",
+	"⚠️ Сид <code>%d</code> получен по старой схеме: состав и веса жеребьёвки им не закреплены\n": "⚠️ Seed <code>%d</code> uses the old scheme: it does not cover the voters and their weights\n",
+	"❌ Сид не соответствует секрету\n":                                                            "❌ The seed does not match the secret\n",
+	"✅ Пересчитанная очередь совпадает: %s, порядок: %s\n":                                        "✅ The recomputed queue matches: %s, order: %s\n",
+	"❌ Пересчитанная очередь не совпадает с опубликованной\n":                                     "❌ The recomputed queue does not match the published one\n",
+	"\n🎉 <b>Очередь честная</b>":                                                                  "\n🎉 <b>The queue is fair</b>",
+	"\n⚠️ <b>Проверка не пройдена</b>":                                                            "\n⚠️ <b>Verification failed</b>",
+
+	// Roles
+	"участник":                 "member",
+	"ведущий":                  "host",
+	"администратор":            "admin",
+	"владелец":                 "owner",
+	"все участники":            "all members",
+	"ведущие и администраторы": "hosts and admins",
+	"только администраторы":    "admins only",
+	"только владелец":          "the owner only",
+	"👥 <b>Роли</b>\n\n" +
+		"Ответьте на сообщение участника:\n" +
+		"<code>/role host</code> — ведущий: закрывает опросы и ведёт очередь\n" +
+		"<code>/role admin</code> — администратор бота (назначает владелец)\n" +
+		"<code>/role member</code> — снять роль\n\n" +
+		"Кто создаёт опросы: <code>/role polls member|host|admin</code>": "👥 <b>Roles</b>\n\n" +
+		"Reply to a member's message with:\n" +
+		"<code>/role host</code> — host: closes polls and runs the queue\n" +
+		"<code>/role admin</code> — bot admin (granted by the owner)\n" +
+		"<code>/role member</code> — remove the role\n\n" +
+		"Who creates polls: <code>/role polls member|host|admin</code>",
+	"⛔ Менять роли могут только администраторы чата.":                  "⛔ Only chat admins can change roles.",
+	"✅ Создавать опросы могут %s.":                                     "✅ Polls can now be created by %s.",
+	"⛔ Назначать администраторов может только владелец чата.":          "⛔ Only the chat owner can appoint admins.",
+	"⛔ Роль этого участника может изменить только владелец чата.":      "⛔ Only the chat owner can change this member's role.",
+	"\nℹ️ В Telegram участник остаётся: %s":                            "\nℹ️ In Telegram the member remains: %s",
+	"👥 <b>Роли в чате</b>\n\n":                                         "👥 <b>Chat roles</b>\n\n",
+	"👑 Владелец и администраторы Telegram — администраторы\n":          "👑 The owner and Telegram admins are admins\n",
+	"\n🗳 Создавать опросы могут %s.\n\n":                               "\n🗳 Polls can be created by %s.\n\n",
+	"Изменить: <code>/role host</code> в ответ на сообщение участника": "Change: <code>/role host</code> in reply to a member's message",
+
+	// Schedules
+	"вс": "Sun",
+	"пн": "Mon",
+	"вт": "Tue",
+	"ср": "Wed",
+	"чт": "Thu",
+	"пт": "Fri",
+	"сб": "Sat",
+	"📅 <b>Регулярные опросы</b>\n\n" +
+		"<code>/schedule Тема | вт 18:00 | 30m</code> — создавать опрос каждую неделю\n" +
+		"<code>/schedule Тема | вт 18:00 | 30m | 20</code> — то же с ограничением мест\n" +
+		"<code>/schedule Тема | вт 18:00</code> — длительность по умолчанию\n" +
+		"<code>/schedule list</code> — список расписаний\n" +
+		"<code>/schedule pause 1</code>, <code>/schedule resume 1</code>, <code>/schedule delete 1</code>": "📅 <b>Recurring polls</b>\n\n" +
+		"<code>/schedule Topic | tue 18:00 | 30m</code> — create a poll every week\n" +
+		"<code>/schedule Topic | tue 18:00 | 30m | 20</code> — the same with limited places\n" +
+		"<code>/schedule Topic | tue 18:00</code> — with the default duration\n" +
+		"<code>/schedule list</code> — list the schedules\n" +
+		"<code>/schedule pause 1</code>, <code>/schedule resume 1</code>, <code>/schedule delete 1</code>",
+	"⛔ Управлять расписанием могут только администраторы чата.": "⛔ Only chat admins can manage schedules.",
+	"❌ Расписание #%d не найдено":                               "❌ Schedule #%d not found",
+	"⏸ Расписание #%d приостановлено":                           "⏸ Schedule #%d paused",
+	"▶️ Расписание #%d возобновлено":                            "▶️ Schedule #%d resumed",
+	"🗑 Расписание #%d удалено":                                  "🗑 Schedule #%d deleted",
+	"✅ Расписание #%d создано\n\n%s\n\nБлижайший опрос: %s":     "✅ Schedule #%d created\n\n%s\n\nNext poll: %s",
+	"📋 %s — каждую неделю, %s %02d:%02d (%s), ⏰ %s":             "📋 %s — every week, %s %02d:%02d (%s), ⏰ %s",
+	"📅 Регулярных опросов нет.\n\n":                             "📅 There are no recurring polls.\n\n",
+	"📅 <b>Регулярные опросы:</b>\n\n":                           "📅 <b>Recurring polls:</b>\n\n",
+
+	// Settings
+	"📋 Отправьте темы опросов, по одной на строке.": "📋 Send the poll topics, one per line.",
+	"⏰ Отправьте варианты длительности через пробел, например <code>15m 30m* 1h 2h</code>. Звёздочкой отметьте длительность по умолчанию, иначе ею станет первая.": "⏰ Send the duration options separated by spaces, e.g. <code>15m 30m* 1h 2h</code>. Star the default duration, otherwise the first one is used.",
+	"↔️ Отправьте минимальную и максимальную длительность через пробел, например <code>1m 168h</code>.":                                                            "↔️ Send the minimum and maximum duration separated by a space, e.g. <code>1m 168h</code>.",
+	"🌍 Отправьте часовой пояс в формате IANA, например <code>Europe/Moscow</code>.":                                                                                "🌍 Send the timezone in IANA format, e.g. <code>Europe/Moscow</code>.",
+	"🗳 Отправьте два варианта ответа, каждый на своей строке: сначала «иду», затем «не иду».":                                                                      "🗳 Send the two answers, each on its own line: \"coming\" first, then \"not coming\".",
+	"⚙️ Настройки сохранены.":                                                             "⚙️ Settings saved.",
+	"❌ Нужно от 1 до %d тем. Попробуйте ещё раз:":                                         "❌ Send from 1 to %d topics. Please try again:",
+	"❌ Неверный формат. Пример: <code>15m 30m* 1h 2h</code>":                              "❌ Invalid format. Example: <code>15m 30m* 1h 2h</code>",
+	"❌ Неверный формат. Пример: <code>1m 168h</code> (не меньше минуты и не больше 720h)": "❌ Invalid format. Example: <code>1m 168h</code> (at least a minute and at most 720h)",
+	"❌ Нужно ровно два варианта, каждый на своей строке. Попробуйте ещё раз:":             "❌ Send exactly two answers, each on its own line. Please try again:",
+	"⚙️ <b>Настройки чата</b>\n\n":                                                        "⚙️ <b>Chat settings</b>\n\n",
+	"📋 <b>Темы:</b> %s\n":                     "📋 <b>Topics:</b> %s\n",
+	"⏰ <b>Длительности:</b> %s\n":             "⏰ <b>Durations:</b> %s\n",
+	"↔️ <b>Допустимо:</b> от %s до %s\n":      "↔️ <b>Allowed:</b> from %s to %s\n",
+	"🌍 <b>Часовой пояс:</b> %s\n":             "🌍 <b>Timezone:</b> %s\n",
+	"🗳 <b>Варианты ответа:</b> «%s» / «%s»\n": "🗳 <b>Answers:</b> \"%s\" / \"%s\"\n",
+	"🔀 <b>Порядок очереди:</b> %s\n":          "🔀 <b>Queue order:</b> %s\n",
+	"🌐 <b>Язык чата:</b> %s":                  "🌐 <b>Chat language:</b> %s",
+	"📋 Темы":                                  "📋 Topics",
+	"⏰ Длительности":                          "⏰ Durations",
+	"↔️ Пределы":                              "↔️ Limits",
+	"🌍 Часовой пояс":                          "🌍 Timezone",
+	"🗳 Варианты ответа":                       "🗳 Answers",
+	"🔀 Порядок очереди":                       "🔀 Queue order",
+	"🌐 Язык":                                  "🌐 Language",
+
+	// Strategy
+	"🎲 случайный порядок":                                                                                      "🎲 random order",
+	"⚖️ честный порядок (учитывает прошлые очереди)":                                                           "⚖️ fair order (takes past queues into account)",
+	"🔀 <b>Порядок очереди:</b> %s\n\nИзменить: <code>/strategy uniform</code> или <code>/strategy fair</code>": "🔀 <b>Queue order:</b> %s\n\nChange: <code>/strategy uniform</code> or <code>/strategy fair</code>",
+	"❌ Неизвестный порядок. Доступны: <code>uniform</code>, <code>fair</code>":                                 "❌ Unknown order. Available: <code>uniform</code>, <code>fair</code>",
+	"⛔ Менять порядок очереди могут только администраторы чата.":                                               "⛔ Only chat admins can change the queue order.",
+	"✅ <b>Порядок очереди:</b> %s":                                                                             "✅ <b>Queue order:</b> %s",
+
+	// Timezone
+	"🌍 <b>Часовой пояс:</b> %s (сейчас %s)\n\nИзменить: <code>/timezone Europe/Moscow</code>":  "🌍 <b>Timezone:</b> %s (now %s)\n\nChange: <code>/timezone Europe/Moscow</code>",
+	"❌ Неизвестный часовой пояс. Используйте формат IANA, например <code>Europe/Moscow</code>": "❌ Unknown timezone. Use the IANA format, e.g. <code>Europe/Moscow</code>",
+	"⛔ Менять часовой пояс могут только администраторы чата.":                                  "⛔ Only chat admins can change the timezone.",
+	"✅ <b>Часовой пояс:</b> %s": "✅ <b>Timezone:</b> %s",
+
+	// Language
+	"🌐 <b>Язык чата:</b> %s\n\nИзменить: %s":               "🌐 <b>Chat language:</b> %s\n\nChange: %s",
+	"❌ Неизвестный язык. Доступны: %s":                     "❌ Unknown language. Available: %s",
+	"⛔ Менять язык чата могут только администраторы чата.": "⛔ Only chat admins can change the chat language.",
+	"✅ <b>Язык чата:</b> %s":                               "✅ <b>Chat language:</b> %s",
+
+	// Lineup template
+	"свой":        "custom",
+	"стандартный": "default",
+	"🧩 <b>Шаблон очереди</b> (%s):\n<pre>%s</pre>\n\n": "🧩 <b>Queue template</b> (%s):\n<pre>%s</pre>\n\n",
+	`Шаблон задаёт одну строку очереди (Go <code>text/template</code>, разметка HTML). Доступно:
+• <code>{{.Position}}</code> — номер в очереди
+• <code>{{.Mention}}</code> — @username (Имя) или имя со ссылкой на профиль
+• <code>{{.Name}}</code>, <code>{{.Username}}</code>, <code>{{.UserID}}</code> — имя, username и ID
+• <code>{{.Status}}</code> — waiting, current, done или skipped
+• <code>{{.Time}}</code> — ожидаемое время выступления, может быть пустым
+• <code>{{.Waitlist}}</code> — участник в листе ожидания
+
+Изменить: <code>/template</code> и шаблон с новой строки
+Проверить без сохранения: <code>/template preview</code> и шаблон
+Вернуть стандартный: <code>/template reset</code>`: `The template draws one queue line (Go <code>text/template</code>, HTML markup). Available:
+• <code>{{.Position}}</code> — position in the queue
+• <code>{{.Mention}}</code> — @username (Name) or the name linked to the profile
+• <code>{{.Name}}</code>, <code>{{.Username}}</code>, <code>{{.UserID}}</code> — name, username and ID
+• <code>{{.Status}}</code> — waiting, current, done or skipped
+• <code>{{.Time}}</code> — estimated start, may be empty
+• <code>{{.Waitlist}}</code> — the participant is on the waitlist
+
+Change: <code>/template</code> and the template on a new line
+Check without saving: <code>/template preview</code> and the template
+Restore the default: <code>/template reset</code>`,
+	"✅ Восстановлен стандартный шаблон очереди.":                              "✅ The default queue template is restored.",
+	"❌ Ошибка в шаблоне: %s":                                                  "❌ Template error: %s",
+	"❌ Telegram не принял разметку шаблона. Проверьте, что все теги закрыты.": "❌ Telegram rejected the template markup. Check that all tags are closed.",
+	"✅ Шаблон очереди сохранён.":                                              "✅ Queue template saved.",
+	"👀 <b>Предпросмотр</b>\n\n":                                               "👀 <b>Preview</b>\n\n",
+}
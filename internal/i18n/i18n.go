@@ -0,0 +1,152 @@
+// Package i18n translates the bot's messages. Texts are written in Russian in
+// the code and looked up in a per-language catalogue, gettext style; a text
+// missing from a catalogue is shown in Russian.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nikitkaralius/lineup/internal/render"
+)
+
+// Lang is a supported language, named by its ISO 639-1 code.
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// Default is the language of chats that never picked one.
+const Default = Russian
+
+// Supported lists the languages with a catalogue, the default first.
+var Supported = []Lang{Russian, English}
+
+// catalogues map a Russian source text to its translation
+var catalogues = map[Lang]map[string]string{
+	English: english,
+}
+
+// Name returns the name of the language in itself, e.g. "English".
+func (l Lang) Name() string {
+	switch l {
+	case Russian:
+		return "Русский"
+	case English:
+		return "English"
+	}
+	return string(l)
+}
+
+// Parse returns the supported language of an IETF tag such as "en-US", as
+// sent in Telegram's language_code.
+func Parse(tag string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, l := range Supported {
+		if string(l) == base {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Resolve picks the language to talk to a user in: their Telegram language
+// when it is supported, otherwise the chat's.
+func Resolve(chat Lang, userTag string) Lang {
+	if l, ok := Parse(userTag); ok {
+		return l
+	}
+	if chat == "" {
+		return Default
+	}
+	return chat
+}
+
+// Printer formats texts in one language. A nil Printer uses the default.
+type Printer struct {
+	lang Lang
+}
+
+var printers = func() map[Lang]*Printer {
+	res := make(map[Lang]*Printer, len(Supported))
+	for _, l := range Supported {
+		res[l] = &Printer{lang: l}
+	}
+	return res
+}()
+
+// For returns the printer of lang, or of the default for unknown ones.
+func For(lang Lang) *Printer {
+	if p, ok := printers[lang]; ok {
+		return p
+	}
+	return printers[Default]
+}
+
+// Lang returns the language of p.
+func (p *Printer) Lang() Lang {
+	if p == nil {
+		return Default
+	}
+	return p.lang
+}
+
+// translate returns the translation of the Russian source text.
+func (p *Printer) translate(source string) string {
+	if text, ok := catalogues[p.Lang()][source]; ok {
+		return text
+	}
+	return source
+}
+
+// T translates a plain text, formatting args into it like fmt.Sprintf.
+func (p *Printer) T(source string, args ...any) string {
+	text := p.translate(source)
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// HTML translates markup, formatting args into it like render.Sprintf, so
+// user content is escaped.
+func (p *Printer) HTML(source string, args ...any) string {
+	text := p.translate(source)
+	if len(args) == 0 {
+		return text
+	}
+	return render.Sprintf(text, args...)
+}
+
+type localeKey struct{}
+
+type locale struct {
+	user, chat *Printer
+}
+
+// WithLocale returns ctx carrying the language of the user an update came
+// from and the language of its chat.
+func WithLocale(ctx context.Context, user, chat Lang) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale{user: For(user), chat: For(chat)})
+}
+
+// ForUser returns the printer for texts meant for the user behind the update
+// in ctx: replies, wizards and button notices.
+func ForUser(ctx context.Context) *Printer {
+	if l, ok := ctx.Value(localeKey{}).(locale); ok {
+		return l.user
+	}
+	return For(Default)
+}
+
+// ForChat returns the printer for texts the whole chat reads, like polls and
+// lineups.
+func ForChat(ctx context.Context) *Printer {
+	if l, ok := ctx.Value(localeKey{}).(locale); ok {
+		return l.chat
+	}
+	return For(Default)
+}
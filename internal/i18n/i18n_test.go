@@ -0,0 +1,46 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		chat Lang
+		tag  string
+		want Lang
+	}{
+		{Russian, "en-US", English},
+		{English, "ru", Russian},
+		{English, "de", English},
+		{"", "", Default},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.chat, tt.tag); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.chat, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLEscapesArguments(t *testing.T) {
+	got := For(English).HTML("🔔 Ваша очередь, %s!", "<b>A & B</b>")
+	want := "🔔 Your turn, &lt;b&gt;A &amp; B&lt;/b&gt;!"
+	if got != want {
+		t.Errorf("HTML = %q, want %q", got, want)
+	}
+}
+
+// verbs matches fmt verbs such as %s and %d, skipping "%%"
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCataloguesKeepFormatVerbs(t *testing.T) {
+	for lang, catalogue := range catalogues {
+		for source, text := range catalogue {
+			if want, got := verbs.FindAllString(source, -1), verbs.FindAllString(text, -1); !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, its source %q has %v", lang, text, got, source, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Noun is a counted word with plural forms in every language.
+type Noun int
+
+const (
+	Minutes Noun = iota
+	Hours
+	Participants
+	Places
+)
+
+// nouns hold the forms of each noun: one, few and many for Russian, one and
+// other for English
+var nouns = map[Lang]map[Noun][]string{
+	Russian: {
+		Minutes:      {"минута", "минуты", "минут"},
+		Hours:        {"час", "часа", "часов"},
+		Participants: {"участник", "участника", "участников"},
+		Places:       {"место", "места", "мест"},
+	},
+	English: {
+		Minutes:      {"minute", "minutes"},
+		Hours:        {"hour", "hours"},
+		Participants: {"participant", "participants"},
+		Places:       {"place", "places"},
+	},
+}
+
+// pluralForm returns the index of the form of a noun counted n times.
+func pluralForm(lang Lang, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case Russian:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
+
+// Plural returns the form of noun that goes with n, without the number.
+func (p *Printer) Plural(n int, noun Noun) string {
+	forms := nouns[p.Lang()][noun]
+	return forms[pluralForm(p.Lang(), n)]
+}
+
+// Count renders n with its noun, e.g. "21 участник", "5 minutes".
+func (p *Printer) Count(n int, noun Noun) string {
+	return fmt.Sprintf("%d %s", n, p.Plural(n, noun))
+}
+
+// Duration renders d in hours and minutes, e.g. "1 час 30 минут".
+func (p *Printer) Duration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if hours > 0 {
+		parts = append(parts, p.Count(hours, Hours))
+	}
+	if minutes > 0 || hours == 0 {
+		parts = append(parts, p.Count(minutes, Minutes))
+	}
+	return strings.Join(parts, " ")
+}
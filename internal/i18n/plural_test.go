@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		n      int
+		ru, en string
+	}{
+		{1, "1 участник", "1 participant"},
+		{2, "2 участника", "2 participants"},
+		{5, "5 участников", "5 participants"},
+		{11, "11 участников", "11 participants"},
+		{12, "12 участников", "12 participants"},
+		{14, "14 участников", "14 participants"},
+		{21, "21 участник", "21 participants"},
+		{22, "22 участника", "22 participants"},
+		{25, "25 участников", "25 participants"},
+		{111, "111 участников", "111 participants"},
+		{0, "0 участников", "0 participants"},
+	}
+	for _, tt := range tests {
+		if got := For(Russian).Count(tt.n, Participants); got != tt.ru {
+			t.Errorf("ru Count(%d) = %q, want %q", tt.n, got, tt.ru)
+		}
+		if got := For(English).Count(tt.n, Participants); got != tt.en {
+			t.Errorf("en Count(%d) = %q, want %q", tt.n, got, tt.en)
+		}
+	}
+}
+
+func TestPluralEveryNoun(t *testing.T) {
+	tests := []struct {
+		noun Noun
+		n    int
+		ru   string
+		en   string
+	}{
+		{Minutes, 1, "минута", "minute"},
+		{Minutes, 22, "минуты", "minutes"},
+		{Minutes, 11, "минут", "minutes"},
+		{Hours, 21, "час", "hours"},
+		{Hours, 3, "часа", "hours"},
+		{Hours, 111, "часов", "hours"},
+		{Places, 101, "место", "places"},
+		{Places, 104, "места", "places"},
+		{Places, 112, "мест", "places"},
+	}
+	for _, tt := range tests {
+		if got := For(Russian).Plural(tt.n, tt.noun); got != tt.ru {
+			t.Errorf("ru Plural(%d, %d) = %q, want %q", tt.n, tt.noun, got, tt.ru)
+		}
+		if got := For(English).Plural(tt.n, tt.noun); got != tt.en {
+			t.Errorf("en Plural(%d, %d) = %q, want %q", tt.n, tt.noun, got, tt.en)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d      time.Duration
+		ru, en string
+	}{
+		{0, "0 минут", "0 minutes"},
+		{time.Minute, "1 минута", "1 minute"},
+		{21 * time.Minute, "21 минута", "21 minutes"},
+		{time.Hour, "1 час", "1 hour"},
+		{2*time.Hour + 30*time.Minute, "2 часа 30 минут", "2 hours 30 minutes"},
+		{25 * time.Hour, "25 часов", "25 hours"},
+	}
+	for _, tt := range tests {
+		if got := For(Russian).Duration(tt.d); got != tt.ru {
+			t.Errorf("ru Duration(%s) = %q, want %q", tt.d, got, tt.ru)
+		}
+		if got := For(English).Duration(tt.d); got != tt.en {
+			t.Errorf("en Duration(%s) = %q, want %q", tt.d, got, tt.en)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/nikitkaralius/lineup/internal/conversations"
+	"github.com/riverqueue/river"
+)
+
+type CleanupConversationsWorker struct {
+	river.WorkerDefaults[conversations.CleanupArgs]
+	states *conversations.PostgresStore
+}
+
+func NewCleanupConversationsWorker(states *conversations.PostgresStore) *CleanupConversationsWorker {
+	return &CleanupConversationsWorker{states: states}
+}
+
+func (w *CleanupConversationsWorker) Work(ctx context.Context, job *river.Job[conversations.CleanupArgs]) error {
+	n, err := w.states.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("removed %d expired conversation states", n)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nikitkaralius/lineup/internal/updates"
+	"github.com/riverqueue/river"
+)
+
+type CleanupUpdatesWorker struct {
+	river.WorkerDefaults[updates.CleanupArgs]
+	updates *updates.Repository
+}
+
+func NewCleanupUpdatesWorker(updates *updates.Repository) *CleanupUpdatesWorker {
+	return &CleanupUpdatesWorker{updates: updates}
+}
+
+func (w *CleanupUpdatesWorker) Work(ctx context.Context, job *river.Job[updates.CleanupArgs]) error {
+	n, err := w.updates.DeleteOlderThan(ctx, time.Now().Add(-updates.Retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("removed %d processed update ids", n)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/nikitkaralius/lineup/internal/voters"
+	"github.com/riverqueue/river"
+)
+
+type ExpireSwapOfferWorker struct {
+	river.WorkerDefaults[polls.ExpireSwapOfferArgs]
+	voters *voters.Repository
+	chats  *chats.Repository
+	bot    telegram.Client
+}
+
+func NewExpireSwapOfferWorker(voters *voters.Repository, chats *chats.Repository, bot telegram.Client) *ExpireSwapOfferWorker {
+	return &ExpireSwapOfferWorker{voters: voters, chats: chats, bot: bot}
+}
+
+func (w *ExpireSwapOfferWorker) Work(ctx context.Context, job *river.Job[polls.ExpireSwapOfferArgs]) error {
+	err := w.voters.CloseSwapOffer(ctx, job.Args.OfferID, voters.SwapStatusExpired)
+	if errors.Is(err, voters.ErrSwapOfferClosed) {
+		// Answered in time, nothing to do
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	offer, err := w.voters.GetSwapOffer(ctx, job.Args.OfferID)
+	if err != nil {
+		return err
+	}
+	if offer.MessageID == 0 {
+		return nil
+	}
+	lang, err := w.chats.GetLanguage(ctx, offer.ChatID)
+	if err != nil {
+		return err
+	}
+	edit := tgbotapi.NewEditMessageText(offer.ChatID, offer.MessageID, i18n.For(lang).T("⌛ Предложение обмена истекло"))
+	edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	_, _ = w.bot.Send(edit)
+	return nil
+}
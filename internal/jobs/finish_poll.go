@@ -2,109 +2,234 @@ package jobs
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"math/rand"
-	"strings"
+	"sort"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/lineup"
+	"github.com/nikitkaralius/lineup/internal/ordering"
 	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
 	"github.com/nikitkaralius/lineup/internal/voters"
 	"github.com/riverqueue/river"
 )
 
+const (
+	finishPollRetryBase = 10 * time.Second
+	finishPollRetryMax  = 5 * time.Minute
+)
+
 type FinishPollWorker struct {
 	river.WorkerDefaults[polls.FinishPollArgs]
 	polls  *polls.Repository
 	voters *voters.Repository
-	bot    *tgbotapi.BotAPI
+	chats  *chats.Repository
+	bot    telegram.Client
 }
 
-func NewFinishPollWorker(polls *polls.Repository, voters *voters.Repository, bot *tgbotapi.BotAPI) *FinishPollWorker {
-	return &FinishPollWorker{polls: polls, voters: voters, bot: bot}
+func NewFinishPollWorker(polls *polls.Repository, voters *voters.Repository, chats *chats.Repository, bot telegram.Client) *FinishPollWorker {
+	return &FinishPollWorker{polls: polls, voters: voters, chats: chats, bot: bot}
 }
 
+// NextRetry backs off exponentially from 10 seconds up to 5 minutes, so a
+// Telegram or database hiccup delays the lineup by minutes rather than hours.
+func (w *FinishPollWorker) NextRetry(job *river.Job[polls.FinishPollArgs]) time.Time {
+	backoff := finishPollRetryMax
+	if job.Attempt < 6 {
+		backoff = min(finishPollRetryBase<<(job.Attempt-1), finishPollRetryMax)
+	}
+	return time.Now().Add(backoff)
+}
+
+// Work finishes a poll step by step: stop it and draw the lineup, post the
+// results, mark it processed. Each step moves the poll to its next status, so
+// a retried or duplicate job picks up where the last run stopped and never
+// redoes a finished step.
 func (w *FinishPollWorker) Work(ctx context.Context, job *river.Job[polls.FinishPollArgs]) error {
-	args := job.Args
-	// Stop poll in chat
+	for {
+		poll, err := w.polls.GetPoll(ctx, job.Args.PollID)
+		if err != nil {
+			return err
+		}
+		switch poll.Status {
+		case polls.PollStatusActive:
+			if poll.EndsAt.After(time.Now()) {
+				// The poll was extended after this job was scheduled
+				return river.JobSnooze(time.Until(poll.EndsAt))
+			}
+			err = w.stopPoll(ctx, job.Args)
+		case polls.PollStatusStopped:
+			// Claim the results message, so it is only ever sent from the
+			// posting status below
+			err = w.polls.Transition(ctx, poll.PollID, polls.PollStatusStopped, polls.PollStatusPosting, nil)
+		case polls.PollStatusPosting:
+			err = w.postResults(ctx, poll)
+		case polls.PollStatusPosted:
+			err = w.polls.MarkProcessed(ctx, poll.PollID)
+		default:
+			// Processed or cancelled
+			return nil
+		}
+		// A concurrent run got there first; re-read and carry on from its status
+		if err != nil && !errors.Is(err, polls.ErrPollStatusChanged) {
+			return err
+		}
+	}
+}
+
+// stopPoll closes voting and stores the lineup drawn from the final votes.
+func (w *FinishPollWorker) stopPoll(ctx context.Context, args polls.FinishPollArgs) error {
+	// Stop poll in chat and drop its control buttons
 	stopCfg := tgbotapi.NewStopPoll(args.ChatID, args.MessageID)
+	stopCfg.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
 	if _, err := w.bot.Send(stopCfg); err != nil {
-		log.Printf("stop poll error: %v", err)
-		// keep going; maybe already stopped
+		var tgErr *tgbotapi.Error
+		if !errors.As(err, &tgErr) {
+			// Network trouble; retry so no votes arrive after the draw
+			return err
+		}
+		// Already stopped or deleted from the chat
+		log.Printf("stop poll %s: %v", args.PollID, err)
 	}
 	vs, err := w.voters.GetComingVoters(ctx, args.PollID)
 	if err != nil {
 		return err
 	}
-	shuffleVoters(vs)
-	text := formatResults(args.Topic, vs)
-
-	// Create inline keyboard for queue management
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🙋 Войти", fmt.Sprintf("queue_join:%s", args.PollID)),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти", fmt.Sprintf("queue_exit:%s", args.PollID)),
-		),
-	)
+	result, err := w.drawLineup(ctx, args, vs)
+	if err != nil {
+		return err
+	}
+	return w.polls.Transition(ctx, args.PollID, polls.PollStatusActive, polls.PollStatusStopped, func(tx pgx.Tx) error {
+		if err := w.voters.SaveQueueTx(ctx, tx, args.PollID, vs); err != nil {
+			return err
+		}
+		return w.voters.InsertPollResultTx(ctx, tx, result)
+	})
+}
 
-	msg := tgbotapi.NewMessage(args.ChatID, text)
-	msg.ParseMode = "Markdown"
-	msg.ReplyMarkup = keyboard
-	sent, err := w.bot.Send(msg)
+// postResults sends the lineup message of a posting poll and records it. The
+// send happens outside the poll lock, as the rate limited client may wait out
+// a flood limit. The message ID is stored right after the send, so a retry
+// edits the message already in the chat instead of posting it twice.
+func (w *FinishPollWorker) postResults(ctx context.Context, poll *polls.TelegramPollDTO) error {
+	result, err := w.voters.GetPollResult(ctx, poll.PollID)
 	if err != nil {
 		return err
 	}
-	if err := w.polls.MarkProcessed(ctx, args.PollID, sent.MessageID); err != nil {
+	// Read the order back so the message always matches the stored lineup
+	entries, err := w.voters.GetQueue(ctx, poll.PollID)
+	if err != nil {
 		return err
 	}
-	if err := w.voters.InsertPollResult(ctx, args.PollID, text); err != nil {
+	current, err := w.voters.GetCurrentPosition(ctx, poll.PollID)
+	if err != nil {
 		return err
 	}
-	return nil
-}
+	loc, err := w.chats.GetTimezone(ctx, poll.ChatID)
+	if err != nil {
+		return err
+	}
+	lang, err := w.chats.GetLanguage(ctx, poll.ChatID)
+	if err != nil {
+		return err
+	}
+	m := lineup.Message{
+		Poll:     poll,
+		Entries:  entries,
+		Current:  current,
+		Result:   result,
+		Location: loc,
+		Printer:  i18n.For(lang),
+	}
+	res := lineup.ForChat(ctx, w.chats, poll.ChatID).Text(m)
+	text := res.Text
 
-func shuffleVoters(v []voters.TelegramVoterDTO) {
-	for i := range v {
-		j := rand.Intn(i + 1)
-		v[i], v[j] = v[j], v[i]
+	err = w.publish(ctx, poll, text, lineup.Keyboard(m, res))
+	if telegram.IsCantParseEntities(err) {
+		// A chat template can render markup Telegram rejects; retrying the
+		// same text would never succeed, so post the default layout instead
+		log.Printf("lineup of poll %s rejected, using default template: %v", poll.PollID, err)
+		res = lineup.Default().Text(m)
+		text = res.Text
+		err = w.publish(ctx, poll, text, lineup.Keyboard(m, res))
+	}
+	if err != nil {
+		return err
 	}
+	return w.polls.Transition(ctx, poll.PollID, polls.PollStatusPosting, polls.PollStatusPosted, func(tx pgx.Tx) error {
+		return w.voters.SetResultsTextTx(ctx, tx, poll.PollID, text)
+	})
 }
 
-func formatResults(topic string, voters []voters.TelegramVoterDTO) string {
-	var sb strings.Builder
-	sb.WriteString("🎯 *Результаты опроса:* ")
-	sb.WriteString(topic)
-	sb.WriteString("\n\n")
-
-	if len(voters) == 0 {
-		sb.WriteString("😔 *Никто не идет*\n\n")
-		sb.WriteString("💡 Используйте кнопки ниже, чтобы присоединиться к очереди!")
-		return sb.String()
+// publish puts the results text in the chat. The first run sends it and
+// records the message; later runs edit the recorded message.
+func (w *FinishPollWorker) publish(ctx context.Context, poll *polls.TelegramPollDTO, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	if poll.ResultsMessageID != 0 {
+		edit := tgbotapi.NewEditMessageText(poll.ChatID, poll.ResultsMessageID, text)
+		edit.ParseMode = render.ParseMode
+		edit.ReplyMarkup = &keyboard
+		_, err := w.bot.Send(edit)
+		return err
 	}
 
-	sb.WriteString(fmt.Sprintf("👥 *Участников:* %d\n\n", len(voters)))
-	sb.WriteString("🏆 *Очередь участников:*\n")
+	msg := tgbotapi.NewMessage(poll.ChatID, text)
+	msg.ParseMode = render.ParseMode
+	msg.ReplyMarkup = keyboard
+	sent, err := w.bot.Send(msg)
+	if err != nil {
+		return err
+	}
+	poll.ResultsMessageID = sent.MessageID
+	return w.polls.SetResultsMessage(ctx, poll.PollID, sent.MessageID)
+}
 
-	for i, voter := range voters {
-		sb.WriteString(fmt.Sprintf("%d. ", i+1))
-		if voter.Username != "" {
-			sb.WriteString("@")
-			sb.WriteString(voter.Username)
-			if voter.Name != "" {
-				sb.WriteString(" (")
-				sb.WriteString(voter.Name)
-				sb.WriteString(")")
-			}
-		} else if voter.Name != "" {
-			sb.WriteString(voter.Name)
-		} else {
-			sb.WriteString("Аноним")
+// drawLineup orders vs in place with the chat's strategy, seeded from the
+// poll secret, and returns everything needed to recompute the order later.
+func (w *FinishPollWorker) drawLineup(ctx context.Context, args polls.FinishPollArgs, vs []voters.TelegramVoterDTO) (*voters.PollResultDTO, error) {
+	secret, _, err := w.polls.GetSeedSecret(ctx, args.PollID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		// Poll created before seeds were introduced; nothing was announced
+		if secret, err = ordering.NewSecret(); err != nil {
+			return nil, err
 		}
-		sb.WriteString("\n")
+	}
+	strategyName, err := w.chats.GetLineupStrategy(ctx, args.ChatID)
+	if err != nil {
+		return nil, err
 	}
 
-	sb.WriteString("\n💡 *Используйте кнопки ниже для управления очередью*")
-	return sb.String()
+	// Use a canonical input order so the lineup does not depend on vote timing
+	sort.Slice(vs, func(i, j int) bool { return vs[i].UserID < vs[j].UserID })
+	weights, err := ordering.New(strategyName, w.voters).Weights(ctx, args, vs)
+	if err != nil {
+		return nil, err
+	}
+	result := &voters.PollResultDTO{
+		PollID:       args.PollID,
+		SeedSecret:   secret,
+		Strategy:     strategyName,
+		InputUserIDs: make([]int64, len(vs)),
+		Weights:      weights,
+	}
+	for i, v := range vs {
+		result.InputUserIDs[i] = v.UserID
+	}
+	result.Seed = ordering.Seed(secret, args.PollID, result.InputUserIDs, weights)
+
+	ordering.Arrange(rand.New(rand.NewSource(result.Seed)), vs, weights)
+	result.LineupUserIDs = make([]int64, len(vs))
+	for i, v := range vs {
+		result.LineupUserIDs[i] = v.UserID
+	}
+	return result, nil
 }
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/riverqueue/river"
+)
+
+// ReconcilePollsWorker finishes polls that are past their end but have no
+// live finish job, e.g. after a lost enqueue, a discarded job or a wiped job
+// table.
+type ReconcilePollsWorker struct {
+	river.WorkerDefaults[polls.ReconcileArgs]
+	polls *polls.Repository
+}
+
+func NewReconcilePollsWorker(polls *polls.Repository) *ReconcilePollsWorker {
+	return &ReconcilePollsWorker{polls: polls}
+}
+
+func (w *ReconcilePollsWorker) Work(ctx context.Context, job *river.Job[polls.ReconcileArgs]) error {
+	stuck, err := w.polls.FindExpiredActivePolls(ctx)
+	if err != nil {
+		return err
+	}
+	pollsService := polls.NewPollsService(river.ClientFromContext[pgx.Tx](ctx))
+	// One broken poll must not keep the others from being finished
+	for _, p := range stuck {
+		jobID, err := pollsService.SchedulePollFinish(ctx, p.FinishArgs(), time.Now().UTC())
+		if err != nil {
+			log.Printf("reconcile poll %s: %v", p.PollID, err)
+			continue
+		}
+		attempts, err := w.polls.SetReconciledJob(ctx, p.PollID, jobID)
+		if err != nil {
+			log.Printf("reconcile poll %s: %v", p.PollID, err)
+			continue
+		}
+		log.Printf("re-enqueued finish job %d for poll %s (status %s, ended %s, attempt %d of %d)",
+			jobID, p.PollID, p.Status, p.EndsAt, attempts, polls.MaxReconcileAttempts)
+		if attempts == polls.MaxReconcileAttempts {
+			log.Printf("poll %s will not be reconciled again", p.PollID)
+		}
+	}
+	return nil
+}
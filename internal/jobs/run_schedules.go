@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/pollcreate"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/schedules"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+	"github.com/riverqueue/river"
+)
+
+// missedRunGrace is how late a scheduled poll may still be posted, e.g. after
+// the worker was down. Older occurrences are skipped.
+const missedRunGrace = time.Hour
+
+type RunSchedulesWorker struct {
+	river.WorkerDefaults[schedules.RunSchedulesArgs]
+	schedules *schedules.Repository
+	polls     *polls.Repository
+	chats     *chats.Repository
+	bot       telegram.Client
+}
+
+func NewRunSchedulesWorker(schedules *schedules.Repository, polls *polls.Repository, chats *chats.Repository, bot telegram.Client) *RunSchedulesWorker {
+	return &RunSchedulesWorker{schedules: schedules, polls: polls, chats: chats, bot: bot}
+}
+
+func (w *RunSchedulesWorker) Work(ctx context.Context, job *river.Job[schedules.RunSchedulesArgs]) error {
+	now := time.Now().UTC()
+	due, err := w.schedules.ClaimDueSchedules(ctx, now)
+	if err != nil {
+		return err
+	}
+	pollsService := polls.NewPollsService(river.ClientFromContext[pgx.Tx](ctx))
+	for _, s := range due {
+		if now.Sub(s.NextRunAt) > missedRunGrace {
+			log.Printf("skip missed run of schedule %d due at %s", s.ID, s.NextRunAt)
+			continue
+		}
+		loc, err := s.Location()
+		if err != nil {
+			log.Printf("schedule %d timezone error: %v", s.ID, err)
+			continue
+		}
+		settings, err := w.chats.GetSettings(ctx, s.ChatID)
+		if err != nil {
+			return err
+		}
+		lang, err := w.chats.GetLanguage(ctx, s.ChatID)
+		if err != nil {
+			return err
+		}
+		_, err = pollcreate.Create(ctx, w.bot, w.polls, pollsService, pollcreate.Request{
+			ChatID:          s.ChatID,
+			Topic:           s.Topic,
+			Duration:        s.Duration,
+			MaxParticipants: s.MaxParticipants,
+			CreatorID:       s.CreatorID,
+			CreatorUsername: s.CreatorUsername,
+			CreatorName:     s.CreatorName,
+			OptionComing:    settings.OptionComing,
+			OptionNotComing: settings.OptionNotComing,
+			Location:        loc,
+			Printer:         i18n.For(lang),
+		})
+		if err != nil {
+			// The occurrence is already claimed; retrying the whole job would
+			// not post it again, so only log
+			log.Printf("create scheduled poll %d error: %v", s.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package lineup
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Keyboard returns the queue management buttons of the results message res
+// rendered from m. Join and exit carry the page shown, so the message stays
+// on it; a queue longer than one page gets "◀ / ▶" buttons.
+func Keyboard(m Message, res Results) tgbotapi.InlineKeyboardMarkup {
+	pollID, p := m.Poll.PollID, m.Printer
+	page := res.Page
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🙋 Войти"), fmt.Sprintf("queue_join:%s:%d", pollID, page)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🚪 Выйти"), fmt.Sprintf("queue_exit:%s:%d", pollID, page)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("✅ Готово"), fmt.Sprintf("queue_done:%s", pollID)),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("⏭ Пропустить"), fmt.Sprintf("queue_skip:%s", pollID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🔁 Поменяться местами"), fmt.Sprintf("swap_start:%s", pollID)),
+		),
+	}
+	if pages := res.Pages; pages > 1 {
+		// The buttons wrap around, so both are always there
+		prev := (page + pages - 1) % pages
+		next := (page + 1) % pages
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀", fmt.Sprintf("queue_page:%s:%d", pollID, prev)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d / %d", page+1, pages), fmt.Sprintf("queue_page:%s:%d", pollID, CurrentPage)),
+			tgbotapi.NewInlineKeyboardButtonData("▶", fmt.Sprintf("queue_page:%s:%d", pollID, next)),
+		))
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,17 @@
+package lineup
+
+import (
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// Ping tells the chat whose turn it is now.
+func Ping(p *i18n.Printer, v voters.TelegramVoterDTO) string {
+	return p.HTML("🔔 Ваша очередь, %s!", Mention(p, v))
+}
+
+// Promotion tells a waitlisted participant they moved into the main list at
+// position.
+func Promotion(p *i18n.Printer, v voters.TelegramVoterDTO, position int) string {
+	return p.HTML("🎉 %s, освободилось место — вы в основном списке под номером %d!", Mention(p, v), position)
+}
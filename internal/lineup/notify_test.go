@@ -0,0 +1,27 @@
+package lineup
+
+import (
+	"testing"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+)
+
+func TestPingGolden(t *testing.T) {
+	for _, lang := range i18n.Supported {
+		var got string
+		for _, v := range hostileVoters {
+			got += Ping(i18n.For(lang), v) + "\n"
+		}
+		golden(t, "ping_"+string(lang), got)
+	}
+}
+
+func TestPromotionGolden(t *testing.T) {
+	for _, lang := range i18n.Supported {
+		var got string
+		for i, v := range hostileVoters {
+			got += Promotion(i18n.For(lang), v, i+1) + "\n"
+		}
+		golden(t, "promotion_"+string(lang), got)
+	}
+}
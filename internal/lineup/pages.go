@@ -0,0 +1,58 @@
+package lineup
+
+import "github.com/nikitkaralius/lineup/internal/render"
+
+// PageSize is the most queue lines one results page shows; pages with long
+// lines end earlier, so the message fits in MessageLimit.
+const PageSize = 30
+
+// CurrentPage asks for the page with the position presenting now.
+const CurrentPage = -1
+
+// Pages returns how many pages of PageSize items n items take; an empty list
+// still has one.
+func Pages(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return (n + PageSize - 1) / PageSize
+}
+
+// paginate splits lines into pages of at most PageSize lines whose total
+// length, newlines included, stays within budget. It returns the index of
+// the first line of every page; a page always takes at least one line.
+func paginate(lines []string, budget int) []int {
+	starts := []int{0}
+	used, count := 0, 0
+	for i, line := range lines {
+		n := render.Length(line) + 1
+		if count > 0 && (count == PageSize || used+n > budget) {
+			starts = append(starts, i)
+			used, count = 0, 0
+		}
+		used += n
+		count++
+	}
+	return starts
+}
+
+// pageEnd returns the index after the last line of page.
+func pageEnd(starts []int, page, n int) int {
+	if page+1 < len(starts) {
+		return starts[page+1]
+	}
+	return n
+}
+
+// pageOf resolves the requested page of m into the range [0, len(starts)).
+func pageOf(m Message, starts []int) int {
+	page := m.Page
+	if page == CurrentPage {
+		// Positions are contiguous from 1, so position p is entry p-1
+		page = len(starts) - 1
+		for page > 0 && starts[page] > m.Current-1 {
+			page--
+		}
+	}
+	return max(0, min(page, len(starts)-1))
+}
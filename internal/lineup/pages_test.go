@@ -0,0 +1,156 @@
+package lineup
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// queueOf returns m with n waiting entries cycling through hostileVoters.
+func queueOf(m Message, n int) Message {
+	m.Entries = nil
+	for i := range n {
+		v := hostileVoters[i%len(hostileVoters)]
+		m.Entries = append(m.Entries, voters.QueueEntryDTO{TelegramVoterDTO: v, Position: i + 1, Status: voters.QueueStatusWaiting})
+	}
+	m.Current = 1
+	return m
+}
+
+func TestRenderPages(t *testing.T) {
+	m := queueOf(hostileMessage(i18n.For(i18n.Russian)), PageSize+2)
+	m.Poll.MaxParticipants = 0
+	m.Page = 1
+
+	got, err := Default().Render(m)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got.Page != 1 || got.Pages != 2 {
+		t.Errorf("page %d of %d, want 1 of 2", got.Page, got.Pages)
+	}
+	golden(t, "results_page_2", got.Text)
+}
+
+// position matches the position a queue line starts with
+var position = regexp.MustCompile(`(?m)^(?:👉 <b>)?(\d+)\.`)
+
+func TestPagesFitMessageLimit(t *testing.T) {
+	// Lines of up to MaxLineLength characters, most of them escaped
+	longest, err := New(`{{.Position}}.{{.Name}}`)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	name := strings.Repeat("&", MaxLineLength-3)
+
+	// Telegram's longest names and username with a tg:// link and a time
+	longNames := voters.TelegramVoterDTO{UserID: 1 << 40, Name: strings.Repeat("Щ", 64) + " " + strings.Repeat("😀", 64)}
+	longUsername := voters.TelegramVoterDTO{UserID: 2, Username: strings.Repeat("u", 32), Name: longNames.Name}
+
+	tests := []struct {
+		name     string
+		renderer *Renderer
+		voter    voters.TelegramVoterDTO
+	}{
+		{"max length template lines", longest, voters.TelegramVoterDTO{UserID: 3, Name: name}},
+		{"longest link mentions", Default(), longNames},
+		{"longest usernames", Default(), longUsername},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := hostileMessage(i18n.For(i18n.Russian))
+			// Poll questions are at most 300 characters, so is the topic
+			m.Poll.Topic = strings.Repeat("<>", 150)
+			m.Poll.MaxParticipants = 20
+			m.Entries = nil
+			for i := range 3 * PageSize {
+				m.Entries = append(m.Entries, voters.QueueEntryDTO{TelegramVoterDTO: tt.voter, Position: i + 1, Status: voters.QueueStatusWaiting})
+			}
+
+			seen := make(map[string]bool)
+			for page := 0; ; page++ {
+				m.Page = page
+				res, err := tt.renderer.Render(m)
+				if err != nil {
+					t.Fatalf("Render page %d: %v", page, err)
+				}
+				if n := render.Length(res.Text); n > MessageLimit {
+					t.Errorf("page %d is %d characters long, over %d", page, n, MessageLimit)
+				}
+				for _, match := range position.FindAllStringSubmatch(res.Text, -1) {
+					seen[match[1]] = true
+				}
+				if page == res.Pages-1 {
+					break
+				}
+			}
+			if len(seen) != len(m.Entries) {
+				t.Errorf("pages show %d positions, want all %d", len(seen), len(m.Entries))
+			}
+		})
+	}
+}
+
+func TestCurrentPageFollowsPresenter(t *testing.T) {
+	m := queueOf(hostileMessage(i18n.For(i18n.English)), 2*PageSize+5)
+	m.Poll.MaxParticipants = 0
+	m.Page = CurrentPage
+	for _, tt := range []struct{ current, page int }{
+		{1, 0}, {PageSize, 0}, {PageSize + 1, 1}, {2*PageSize + 5, 2}, {2*PageSize + 6, 2},
+	} {
+		m.Current = tt.current
+		res, err := Default().Render(m)
+		if err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+		if res.Page != tt.page {
+			t.Errorf("current %d: page %d, want %d", tt.current, res.Page, tt.page)
+		}
+	}
+}
+
+func TestLineTooLong(t *testing.T) {
+	r, err := New(`{{.Name}}`)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	m := queueOf(hostileMessage(nil), 1)
+	m.Entries[0].Name = strings.Repeat("x", MaxLineLength+1)
+	if _, err := r.Render(m); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("Render = %v, want %v", err, ErrLineTooLong)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	line := strings.Repeat("x", 9) // 10 with the newline
+	lines := make([]string, PageSize+5)
+	for i := range lines {
+		lines[i] = line
+	}
+	tests := []struct {
+		budget int
+		want   []int
+	}{
+		{1 << 20, []int{0, PageSize}},
+		{100, []int{0, 10, 20, 30}},
+		// A page always takes a line, however long
+		{5, func() []int {
+			starts := make([]int, len(lines))
+			for i := range starts {
+				starts[i] = i
+			}
+			return starts
+		}()},
+	}
+	for _, tt := range tests {
+		if got := paginate(lines, tt.budget); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("paginate(budget %d) = %v, want %v", tt.budget, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package lineup
+
+import (
+	"time"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// Preview renders a made-up poll that shows every status, a waitlist and
+// names that need escaping, so admins can check a template before saving it.
+func (r *Renderer) Preview(p *i18n.Printer, loc *time.Location) (string, error) {
+	res, err := r.Render(sample(p, loc))
+	return res.Text, err
+}
+
+func sample(p *i18n.Printer, loc *time.Location) Message {
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 18, 30, 0, 0, loc)
+	return Message{
+		Poll: &polls.TelegramPollDTO{
+			PollID:           "preview",
+			Topic:            p.T("Пример <темы> & шаблона"),
+			MaxParticipants:  3,
+			SessionStartAt:   start,
+			SlotLength:       10 * time.Minute,
+			CurrentStartedAt: start.Add(20 * time.Minute),
+		},
+		// Entries without a username have no user ID: Telegram may refuse
+		// links to users it cannot match, which would fail the preview
+		Entries: []voters.QueueEntryDTO{
+			{TelegramVoterDTO: voters.TelegramVoterDTO{UserID: 1, Username: "ivan_petrov", Name: "Иван *Петров*"}, Position: 1, Status: voters.QueueStatusDone},
+			{TelegramVoterDTO: voters.TelegramVoterDTO{Name: "Мария <Сидорова>"}, Position: 2, Status: voters.QueueStatusSkipped},
+			{TelegramVoterDTO: voters.TelegramVoterDTO{UserID: 3, Username: "anna", Name: "Анна"}, Position: 3, Status: voters.QueueStatusWaiting},
+			{TelegramVoterDTO: voters.TelegramVoterDTO{Name: "Пётр & Co"}, Position: 4, Status: voters.QueueStatusWaiting},
+		},
+		Current:  3,
+		Location: loc,
+		Printer:  p,
+	}
+}
@@ -0,0 +1,256 @@
+// Package lineup renders the results message of a finished poll: the queue
+// of participants with their positions, statuses and estimated times. Each
+// queue line is drawn by a text/template the chat can customise.
+package lineup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// DefaultTemplate draws a queue line the way the bot always has:
+// "1. @user (Name) — 🕐 18:30", struck through once done or skipped and in
+// bold while presenting.
+const DefaultTemplate = `{{$line := printf "%d. %s" .Position .Mention}}
+{{- with .Time}}{{$line = printf "%s — 🕐 %s" $line .}}{{end}}
+{{- if eq .Status "done"}}<s>{{$line}}</s> ✅
+{{- else if eq .Status "skipped"}}<s>{{$line}}</s> ⏭
+{{- else if eq .Status "current"}}👉 <b>{{$line}}</b>
+{{- else}}{{$line}}{{end}}`
+
+// Limits on custom templates. A line is measured the way Telegram counts
+// it, see render.Length; pages are cut so that they fit in MessageLimit.
+const (
+	MaxTemplateLength = 1000
+	MaxLineLength     = 300
+)
+
+// MessageLimit is the longest text Telegram accepts in a message.
+const MessageLimit = 4096
+
+var (
+	ErrTemplateTooLong = errors.New("template too long")
+	ErrLineTooLong     = errors.New("rendered line too long")
+)
+
+// Entry is what the template sees for one queue line. Name and Username are
+// already escaped; Mention is ready markup.
+type Entry struct {
+	Position int
+	UserID   int64
+	Mention  render.HTML
+	Name     string
+	Username string
+	// Status is waiting, current, done or skipped
+	Status string
+	// Time is the estimated start as "15:04", empty when unknown
+	Time string
+	// Waitlist is set for entries past the poll's places
+	Waitlist bool
+}
+
+// StatusCurrent marks the waiting entry presenting now
+const StatusCurrent = "current"
+
+// Message is everything a results message is built from.
+type Message struct {
+	Poll    *polls.TelegramPollDTO
+	Entries []voters.QueueEntryDTO
+	// Current is the position presenting now
+	Current int
+	// Result holds the draw data; nil or without a secret for old polls
+	Result   *voters.PollResultDTO
+	Location *time.Location
+	// Page is the zero-based page of the queue to show, or CurrentPage
+	Page int
+	// Printer speaks the chat's language
+	Printer *i18n.Printer
+}
+
+// Renderer builds results messages with one queue line template.
+type Renderer struct {
+	line *template.Template
+}
+
+var defaultRenderer = mustParse(DefaultTemplate)
+
+// Default returns the renderer of chats without a custom template.
+func Default() *Renderer {
+	return defaultRenderer
+}
+
+// New parses a queue line template; an empty text gives the default.
+func New(text string) (*Renderer, error) {
+	if strings.TrimSpace(text) == "" {
+		return defaultRenderer, nil
+	}
+	if len(text) > MaxTemplateLength {
+		return nil, ErrTemplateTooLong
+	}
+	line, err := template.New("line").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{line: line}
+	// Catch references to unknown fields before the template is ever used
+	if _, err := r.Render(sample(nil, time.UTC)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func mustParse(text string) *Renderer {
+	return &Renderer{line: template.Must(template.New("line").Parse(text))}
+}
+
+// ForChat returns the renderer of a chat, falling back to the default when
+// its template cannot be loaded.
+func ForChat(ctx context.Context, chatsRepo *chats.Repository, chatID int64) *Renderer {
+	text, err := chatsRepo.GetLineupTemplate(ctx, chatID)
+	if err != nil {
+		log.Printf("Error getting lineup template: %v", err)
+		return defaultRenderer
+	}
+	r, err := New(text)
+	if err != nil {
+		log.Printf("Invalid lineup template in chat %d: %v", chatID, err)
+		return defaultRenderer
+	}
+	return r
+}
+
+// Results is a rendered results message.
+type Results struct {
+	Text string
+	// Page is the zero-based page shown out of Pages
+	Page, Pages int
+}
+
+// Text renders m, falling back to the default layout if the chat template
+// fails on it, so a results message is always produced.
+func (r *Renderer) Text(m Message) Results {
+	res, err := r.Render(m)
+	if err != nil && r != defaultRenderer {
+		log.Printf("Lineup template failed, using default: %v", err)
+		res, err = defaultRenderer.Render(m)
+	}
+	if err != nil {
+		log.Printf("Default lineup template failed: %v", err)
+	}
+	return res
+}
+
+// Render builds the results message of m.
+func (r *Renderer) Render(m Message) (Results, error) {
+	poll, p := m.Poll, m.Printer
+	var head strings.Builder
+	head.WriteString(p.HTML("🎯 <b>Результаты опроса:</b> %s\n\n", poll.Topic))
+
+	if len(m.Entries) == 0 {
+		head.WriteString(p.HTML("😔 <b>Никто не идет</b>\n\n"))
+		head.WriteString(p.HTML("💡 Используйте кнопки ниже, чтобы присоединиться к очереди!"))
+		return Results{Text: head.String(), Pages: 1}, nil
+	}
+
+	if poll.MaxParticipants > 0 {
+		head.WriteString(p.HTML("👥 <b>Участников:</b> %d (%s)\n\n", len(m.Entries), p.Count(poll.MaxParticipants, i18n.Places)))
+	} else {
+		head.WriteString(p.HTML("👥 <b>Участников:</b> %d\n\n", len(m.Entries)))
+	}
+	if poll.HasSchedule() {
+		head.WriteString(p.HTML("🗓 <b>Начало:</b> %s, %s на человека\n\n",
+			poll.SessionStartAt.In(m.Location).Format("15:04 02.01.2006 MST"), p.Duration(poll.SlotLength)))
+	}
+	head.WriteString(p.HTML("🏆 <b>Очередь участников:</b>\n"))
+
+	var tail strings.Builder
+	if m.Current > len(m.Entries) {
+		tail.WriteString(p.HTML("\n🏁 <b>Все выступили</b>\n"))
+	}
+	if m.Result != nil && m.Result.SeedSecret != "" {
+		tail.WriteString(p.HTML("\n🔐 <b>Жеребьёвка:</b> сид <code>%d</code>, секрет <code>%s</code>\n", m.Result.Seed, m.Result.SeedSecret))
+		tail.WriteString(p.HTML("Проверить: <code>/verify %s</code>\n", m.Result.PollID))
+	}
+	tail.WriteString(p.HTML("\n💡 <b>Используйте кнопки ниже для управления очередью</b>"))
+
+	lines := make([]string, len(m.Entries))
+	var line bytes.Buffer
+	for i, e := range m.Entries {
+		line.Reset()
+		if err := r.line.Execute(&line, entry(m, e)); err != nil {
+			return Results{}, err
+		}
+		if render.Length(line.String()) > MaxLineLength {
+			return Results{}, ErrLineTooLong
+		}
+		lines[i] = line.String()
+	}
+
+	waitlistHeading := p.HTML("\n⏳ <b>Лист ожидания:</b>\n")
+	// Every page may repeat the waitlist heading and shows its number
+	reserved := render.Length(head.String()) + render.Length(tail.String()) +
+		render.Length(waitlistHeading) + render.Length(p.HTML("\n📄 Страница %d из %d\n", len(lines), len(lines)))
+	pages := paginate(lines, MessageLimit-reserved)
+	page := pageOf(m, pages)
+
+	var sb strings.Builder
+	sb.WriteString(head.String())
+	for i := pages[page]; i < pageEnd(pages, page, len(lines)); i++ {
+		e := m.Entries[i]
+		// A page starting inside the waitlist repeats its heading
+		if poll.MaxParticipants > 0 && (e.Position == poll.MaxParticipants+1 || i == pages[page] && e.Position > poll.MaxParticipants) {
+			sb.WriteString(waitlistHeading)
+		}
+		sb.WriteString(lines[i])
+		sb.WriteString("\n")
+	}
+	if len(pages) > 1 {
+		sb.WriteString(p.HTML("\n📄 Страница %d из %d\n", page+1, len(pages)))
+	}
+	sb.WriteString(tail.String())
+	return Results{Text: sb.String(), Page: page, Pages: len(pages)}, nil
+}
+
+// entry builds the template view of a queue entry.
+func entry(m Message, e voters.QueueEntryDTO) Entry {
+	poll := m.Poll
+	res := Entry{
+		Position: e.Position,
+		UserID:   e.UserID,
+		Mention:  Mention(m.Printer, e.TelegramVoterDTO),
+		Name:     render.Escape(e.Name),
+		Username: render.Escape(e.Username),
+		Status:   e.Status,
+		Waitlist: poll.MaxParticipants > 0 && e.Position > poll.MaxParticipants,
+	}
+	if e.Status == voters.QueueStatusWaiting {
+		if e.Position == m.Current {
+			res.Status = StatusCurrent
+		}
+		if start, ok := poll.EstimatedStart(m.Current, e.Position); ok && !res.Waitlist {
+			res.Time = start.In(m.Location).Format("15:04")
+		}
+	}
+	return res
+}
+
+// Mention renders a participant as "@username (Name)", or as their name
+// linked to their user ID, so everyone mentioned gets a notification.
+func Mention(p *i18n.Printer, v voters.TelegramVoterDTO) render.HTML {
+	name := v.Name
+	if v.Username == "" && name == "" {
+		name = p.T("Аноним")
+	}
+	return render.User(v.UserID, v.Username, name)
+}
@@ -0,0 +1,143 @@
+package lineup
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// hostileTopic has every character that used to break Markdown messages or
+// could inject HTML
+const hostileTopic = "Разбор <задач> & *звёздочки* > 0"
+
+// hostileVoters are participants whose names and usernames need escaping
+var hostileVoters = []voters.TelegramVoterDTO{
+	{UserID: 1, Username: "ivan_petrov", Name: "*bold*"},
+	{UserID: 2, Username: "evil_", Name: "<script>alert(1)</script>"},
+	{UserID: 3, Username: "a_b", Name: "A & B"},
+	{UserID: 4, Username: "star", Name: "*bold*"},
+	{UserID: 5, Username: "under_score_"},
+	// Without a username the name becomes a tg:// link
+	{UserID: 6, Name: "<script>alert(1)</script>"},
+	{UserID: 7, Name: "A & B"},
+}
+
+// hostileMessage is a results message with every status, a waitlist, a
+// schedule and a draw to verify.
+func hostileMessage(p *i18n.Printer) Message {
+	start := time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC)
+	entries := make([]voters.QueueEntryDTO, len(hostileVoters))
+	for i, v := range hostileVoters {
+		entries[i] = voters.QueueEntryDTO{TelegramVoterDTO: v, Position: i + 1, Status: voters.QueueStatusWaiting}
+	}
+	entries[0].Status = voters.QueueStatusDone
+	entries[1].Status = voters.QueueStatusSkipped
+	return Message{
+		Poll: &polls.TelegramPollDTO{
+			PollID:           "5432",
+			Topic:            hostileTopic,
+			MaxParticipants:  4,
+			SessionStartAt:   start,
+			SlotLength:       10 * time.Minute,
+			CurrentStartedAt: start.Add(25 * time.Minute),
+		},
+		Entries: entries,
+		Current: 3,
+		Result: &voters.PollResultDTO{
+			PollID:     "5432",
+			Seed:       -42,
+			SeedSecret: "s3cr<e>t&",
+		},
+		Location: time.UTC,
+		Printer:  p,
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	custom, err := New(`{{.Position}}) {{.Mention}} [{{.Username}}|{{.Name}}]{{with .Time}} в {{.}}{{end}}{{if .Waitlist}} ⏳{{end}}`)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	empty := hostileMessage(nil)
+	empty.Entries = nil
+
+	tests := []struct {
+		name     string
+		renderer *Renderer
+		message  Message
+	}{
+		{"results_ru", Default(), hostileMessage(i18n.For(i18n.Russian))},
+		{"results_en", Default(), hostileMessage(i18n.For(i18n.English))},
+		{"results_custom_template", custom, hostileMessage(i18n.For(i18n.Russian))},
+		{"results_empty", Default(), empty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.renderer.Render(tt.message)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			golden(t, tt.name, got.Text)
+		})
+	}
+}
+
+func TestTextFallsBackToDefault(t *testing.T) {
+	// The preview sample has four entries, so this only fails on real data
+	r, err := New(`{{.Position}}{{if gt .Position 4}}{{index .Time 9}}{{end}}`)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	m := hostileMessage(i18n.For(i18n.Russian))
+
+	want, err := Default().Render(m)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got := r.Text(m); got != want {
+		t.Errorf("Text = %q, want the default layout %q", got, want)
+	}
+}
+
+func TestNewRejectsBadTemplates(t *testing.T) {
+	tests := []struct {
+		name, text string
+	}{
+		{"syntax", "{{.Position"},
+		{"unknown field", "{{.Nope}}"},
+		{"too long", string(make([]byte, MaxTemplateLength+1))},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.text); err == nil {
+			t.Errorf("%s: New accepted %q", tt.name, tt.text)
+		}
+	}
+}
+
+// golden compares got with testdata/<name>.golden, rewriting the file when
+// run with -update.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package ordering
+
+import (
+	"context"
+
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// defaultHistorySize is how many past lineups of the same topic are considered
+const defaultHistorySize = 5
+
+// Fair favours people who ended up near the end of recent lineups of the same
+// chat and topic. Someone who was always last is three times as likely to be
+// drawn first as someone who was always first; newcomers get the middle weight.
+type Fair struct {
+	voters      *voters.Repository
+	HistorySize int
+}
+
+func (f *Fair) Weights(ctx context.Context, args polls.FinishPollArgs, vs []voters.TelegramVoterDTO) ([]float64, error) {
+	userIDs := make([]int64, len(vs))
+	for i, v := range vs {
+		userIDs[i] = v.UserID
+	}
+	history, err := f.voters.GetPlacementHistory(ctx, args.ChatID, args.Topic, args.PollID, userIDs, f.HistorySize)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make([]float64, len(vs))
+	for i, v := range vs {
+		placement, ok := history[v.UserID]
+		if !ok {
+			placement = 0.5
+		}
+		weights[i] = 1 + 2*placement
+	}
+	return weights, nil
+}
@@ -0,0 +1,53 @@
+package ordering
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+)
+
+// NewSecret returns a random hex secret. Its commitment is announced when the
+// poll is created and the secret itself is revealed with the results.
+func NewSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Commit returns the SHA-256 commitment of a secret.
+func Commit(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Seed derives the shuffle seed of a poll from its secret, its poll ID and
+// the draw input: the voters in canonical order and their weights. Changing
+// any of them afterwards changes the published seed, so the input cannot be
+// altered without /verify noticing.
+func Seed(secret string, pollID string, inputUserIDs []int64, weights []float64) int64 {
+	h := sha256.New()
+	h.Write([]byte(secret + ":" + pollID + ":"))
+	var b [8]byte
+	for _, id := range inputUserIDs {
+		binary.BigEndian.PutUint64(b[:], uint64(id))
+		h.Write(b[:])
+	}
+	// A separator keeps a uniform draw apart from one with weights
+	h.Write([]byte(":"))
+	for _, w := range weights {
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(w))
+		h.Write(b[:])
+	}
+	return int64(binary.BigEndian.Uint64(h.Sum(nil)[:8]))
+}
+
+// LegacySeed is the seed of polls drawn before the input was part of it. It
+// does not cover the voters or their weights.
+func LegacySeed(secret string, pollID string) int64 {
+	sum := sha256.Sum256([]byte(secret + ":" + pollID))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
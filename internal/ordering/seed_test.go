@@ -0,0 +1,65 @@
+package ordering
+
+import "testing"
+
+func TestCommit(t *testing.T) {
+	// SHA-256 of "abc"
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := Commit("abc"); got != want {
+		t.Errorf("Commit(abc) = %s, want %s", got, want)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret: %v", err)
+	}
+	b, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret: %v", err)
+	}
+	if len(a) != 32 || a == b {
+		t.Errorf("NewSecret = %q, %q, want two different 32 digit hex strings", a, b)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	ids := []int64{1, 2, 3}
+	weights := []float64{1, 2, 3}
+	seed := Seed("secret", "42", ids, weights)
+	if again := Seed("secret", "42", []int64{1, 2, 3}, []float64{1, 2, 3}); again != seed {
+		t.Errorf("Seed is not deterministic: %d, then %d", seed, again)
+	}
+
+	// Every part of the draw input changes the seed
+	tests := []struct {
+		name    string
+		secret  string
+		pollID  string
+		ids     []int64
+		weights []float64
+	}{
+		{"secret", "secreT", "42", ids, weights},
+		{"poll ID", "secret", "43", ids, weights},
+		{"voter added", "secret", "42", []int64{1, 2, 3, 4}, weights},
+		{"voter replaced", "secret", "42", []int64{1, 2, 4}, weights},
+		{"voters reordered", "secret", "42", []int64{2, 1, 3}, weights},
+		{"weight changed", "secret", "42", ids, []float64{1, 2, 3.0000001}},
+		{"weights dropped", "secret", "42", ids, nil},
+	}
+	for _, tt := range tests {
+		if got := Seed(tt.secret, tt.pollID, tt.ids, tt.weights); got == seed {
+			t.Errorf("%s: Seed did not change", tt.name)
+		}
+	}
+}
+
+func TestLegacySeed(t *testing.T) {
+	if Seed("secret", "42", nil, nil) == LegacySeed("secret", "42") {
+		t.Error("Seed without input equals LegacySeed, so /verify cannot tell them apart")
+	}
+	if LegacySeed("secret", "42") != LegacySeed("secret", "42") {
+		t.Error("LegacySeed is not deterministic")
+	}
+}
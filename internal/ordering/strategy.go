@@ -0,0 +1,77 @@
+package ordering
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+// Available strategy names, as stored in chat settings
+const (
+	StrategyUniform = "uniform"
+	StrategyFair    = "fair"
+)
+
+// Strategy decides how likely each "coming" voter is to end up near the front
+// of the lineup. The actual order is drawn by Arrange, so a lineup can be
+// reproduced from the stored weights and seed alone.
+type Strategy interface {
+	// Weights returns a weight per voter aligned with vs, or nil when every
+	// order is equally likely.
+	Weights(ctx context.Context, args polls.FinishPollArgs, vs []voters.TelegramVoterDTO) ([]float64, error)
+}
+
+// New returns the strategy with the given name, falling back to the uniform
+// shuffle for unknown names.
+func New(name string, votersRepo *voters.Repository) Strategy {
+	switch name {
+	case StrategyFair:
+		return &Fair{voters: votersRepo, HistorySize: defaultHistorySize}
+	default:
+		return Uniform{}
+	}
+}
+
+// IsKnown reports whether name refers to an existing strategy.
+func IsKnown(name string) bool {
+	return name == StrategyUniform || name == StrategyFair
+}
+
+// Uniform is a plain shuffle where every order is equally likely.
+type Uniform struct{}
+
+func (Uniform) Weights(context.Context, polls.FinishPollArgs, []voters.TelegramVoterDTO) ([]float64, error) {
+	return nil, nil
+}
+
+// Arrange orders vs in place. Without weights it is a Fisher-Yates shuffle;
+// with weights it is weighted random sampling without replacement
+// (Efraimidis-Spirakis): each voter draws the key u^(1/w) and higher keys go
+// first. The result only depends on the input order, weights and rng state.
+func Arrange(rng *rand.Rand, vs []voters.TelegramVoterDTO, weights []float64) {
+	if weights == nil {
+		rng.Shuffle(len(vs), func(i, j int) {
+			vs[i], vs[j] = vs[j], vs[i]
+		})
+		return
+	}
+
+	type keyed struct {
+		voter voters.TelegramVoterDTO
+		key   float64
+	}
+	ks := make([]keyed, len(vs))
+	for i, v := range vs {
+		ks[i] = keyed{voter: v, key: math.Pow(rng.Float64(), 1/weights[i])}
+	}
+	sort.SliceStable(ks, func(i, j int) bool {
+		return ks[i].key > ks[j].key
+	})
+	for i := range ks {
+		vs[i] = ks[i].voter
+	}
+}
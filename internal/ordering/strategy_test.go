@@ -0,0 +1,77 @@
+package ordering
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/nikitkaralius/lineup/internal/voters"
+)
+
+func voterList(n int) []voters.TelegramVoterDTO {
+	vs := make([]voters.TelegramVoterDTO, n)
+	for i := range vs {
+		vs[i] = voters.TelegramVoterDTO{UserID: int64(i + 1)}
+	}
+	return vs
+}
+
+func userIDs(vs []voters.TelegramVoterDTO) []int64 {
+	ids := make([]int64, len(vs))
+	for i, v := range vs {
+		ids[i] = v.UserID
+	}
+	return ids
+}
+
+func TestArrangeDeterministic(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+	}{
+		{"uniform", nil},
+		{"weighted", []float64{1, 3, 2, 1.5, 2.5, 1, 3, 2}},
+	}
+	for _, tt := range tests {
+		a, b := voterList(8), voterList(8)
+		Arrange(rand.New(rand.NewSource(7)), a, tt.weights)
+		Arrange(rand.New(rand.NewSource(7)), b, tt.weights)
+		if !slices.Equal(userIDs(a), userIDs(b)) {
+			t.Errorf("%s: same seed gave %v and %v", tt.name, userIDs(a), userIDs(b))
+		}
+
+		// Still a permutation of the input
+		got := userIDs(a)
+		slices.Sort(got)
+		if !slices.Equal(got, userIDs(voterList(8))) {
+			t.Errorf("%s: Arrange lost or duplicated voters: %v", tt.name, userIDs(a))
+		}
+	}
+}
+
+func TestArrangeDependsOnSeed(t *testing.T) {
+	a, b := voterList(8), voterList(8)
+	Arrange(rand.New(rand.NewSource(1)), a, nil)
+	Arrange(rand.New(rand.NewSource(2)), b, nil)
+	if slices.Equal(userIDs(a), userIDs(b)) {
+		t.Errorf("seeds 1 and 2 gave the same order %v", userIDs(a))
+	}
+}
+
+func TestArrangeFavoursHeavyWeights(t *testing.T) {
+	// Voter 2 has three times the weight of voter 1, so it should go first in
+	// about three draws out of four
+	const draws = 10000
+	first := 0
+	rng := rand.New(rand.NewSource(1))
+	for range draws {
+		vs := voterList(2)
+		Arrange(rng, vs, []float64{1, 3})
+		if vs[0].UserID == 2 {
+			first++
+		}
+	}
+	if share := float64(first) / draws; share < 0.72 || share > 0.78 {
+		t.Errorf("heavier voter went first in %.3f of draws, want about 0.75", share)
+	}
+}
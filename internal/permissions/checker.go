@@ -0,0 +1,118 @@
+package permissions
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/chats"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// AdminCacheTTL is how long the administrators of a chat are remembered;
+// promotions in Telegram take effect after at most this long.
+const AdminCacheTTL = 5 * time.Minute
+
+type chatAdmins struct {
+	ownerID   int64
+	admins    map[int64]bool
+	fetchedAt time.Time
+}
+
+// Checker resolves the role of a user from the chat administrators, fetched
+// with getChatAdministrators and cached per chat, and the granted roles.
+type Checker struct {
+	bot   telegram.Client
+	roles *Repository
+	chats *chats.Repository
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[int64]*chatAdmins
+}
+
+func NewChecker(bot telegram.Client, roles *Repository, chats *chats.Repository, ttl time.Duration) *Checker {
+	return &Checker{bot: bot, roles: roles, chats: chats, ttl: ttl, cache: make(map[int64]*chatAdmins)}
+}
+
+// Role returns the highest role the user has in the chat. Lookup failures
+// count as RoleMember, so errors never grant rights.
+func (c *Checker) Role(ctx context.Context, chatID, userID int64) Role {
+	role := RoleMember
+	if admins := c.admins(chatID); admins != nil {
+		switch {
+		case admins.ownerID == userID:
+			return RoleOwner
+		case admins.admins[userID]:
+			role = RoleAdmin
+		}
+	}
+	granted, err := c.roles.GetRole(ctx, chatID, userID)
+	if err != nil {
+		log.Printf("Error getting chat role: %v", err)
+	}
+	return max(role, granted)
+}
+
+// IsAdmin reports whether the user may change the chat settings.
+func (c *Checker) IsAdmin(ctx context.Context, chatID, userID int64) bool {
+	return c.Role(ctx, chatID, userID) >= RoleAdmin
+}
+
+// CanCreatePolls reports whether the user has the role the chat requires for
+// creating polls.
+func (c *Checker) CanCreatePolls(ctx context.Context, chatID, userID int64) bool {
+	return c.Role(ctx, chatID, userID) >= c.PollCreatorRole(ctx, chatID)
+}
+
+// PollCreatorRole returns the lowest role allowed to create polls in the chat.
+func (c *Checker) PollCreatorRole(ctx context.Context, chatID int64) Role {
+	settings, err := c.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		log.Printf("Error getting chat settings: %v", err)
+		return RoleMember
+	}
+	role, _ := ParseRole(settings.PollCreatorRole)
+	return role
+}
+
+// CanManagePoll reports whether the user may close a poll or drive its
+// queue: its creator or a host.
+func (c *Checker) CanManagePoll(ctx context.Context, chatID, userID, creatorID int64) bool {
+	return userID == creatorID || c.Role(ctx, chatID, userID) >= RoleHost
+}
+
+// admins returns the cached administrators of the chat, refreshing them when
+// stale. A failed refresh keeps using the stale list.
+func (c *Checker) admins(chatID int64) *chatAdmins {
+	c.mu.Lock()
+	cached := c.cache[chatID]
+	c.mu.Unlock()
+	if cached != nil && time.Since(cached.fetchedAt) < c.ttl {
+		return cached
+	}
+
+	members, err := c.bot.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		log.Printf("Error getting chat administrators: %v", err)
+		return cached
+	}
+	fresh := &chatAdmins{admins: make(map[int64]bool, len(members)), fetchedAt: time.Now()}
+	for _, m := range members {
+		if m.User == nil {
+			continue
+		}
+		if m.IsCreator() {
+			fresh.ownerID = m.User.ID
+		}
+		fresh.admins[m.User.ID] = true
+	}
+	c.mu.Lock()
+	c.cache[chatID] = fresh
+	c.mu.Unlock()
+	return fresh
+}
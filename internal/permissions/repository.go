@@ -0,0 +1,77 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Grant is a role given to a user inside the bot.
+type Grant struct {
+	UserID    int64
+	Username  string
+	Name      string
+	Role      Role
+	GrantedBy int64
+	CreatedAt time.Time
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetRole returns the role granted to the user, RoleMember if none.
+func (s *Repository) GetRole(ctx context.Context, chatID, userID int64) (Role, error) {
+	var name string
+	err := s.DB.QueryRow(ctx, `SELECT role FROM chat_roles WHERE chat_id=$1 AND user_id=$2`, chatID, userID).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RoleMember, nil
+	}
+	if err != nil {
+		return RoleMember, err
+	}
+	role, _ := ParseRole(name)
+	return role, nil
+}
+
+// SetRole grants g.Role to g.UserID; granting RoleMember removes the grant.
+func (s *Repository) SetRole(ctx context.Context, chatID int64, g Grant) error {
+	if g.Role == RoleMember {
+		_, err := s.DB.Exec(ctx, `DELETE FROM chat_roles WHERE chat_id=$1 AND user_id=$2`, chatID, g.UserID)
+		return err
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO chat_roles (chat_id, user_id, username, name, role, granted_by, created_at)
+	VALUES ($1,$2,$3,$4,$5,$6,NOW())
+	ON CONFLICT (chat_id, user_id) DO UPDATE SET username=EXCLUDED.username, name=EXCLUDED.name,
+		role=EXCLUDED.role, granted_by=EXCLUDED.granted_by, created_at=NOW()`,
+		chatID, g.UserID, g.Username, g.Name, g.Role.String(), g.GrantedBy)
+	return err
+}
+
+// ListGrants returns the roles granted in the chat, highest first.
+func (s *Repository) ListGrants(ctx context.Context, chatID int64) ([]Grant, error) {
+	rows, err := s.DB.Query(ctx, `SELECT user_id, COALESCE(username,''), COALESCE(name,''), role, granted_by, created_at
+	FROM chat_roles WHERE chat_id=$1 ORDER BY role='admin' DESC, created_at`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []Grant
+	for rows.Next() {
+		var g Grant
+		var name string
+		if err := rows.Scan(&g.UserID, &g.Username, &g.Name, &name, &g.GrantedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		g.Role, _ = ParseRole(name)
+		res = append(res, g)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,37 @@
+// Package permissions decides what a chat member may do with the bot. Owners
+// and administrators come from Telegram; hosts and extra administrators are
+// granted inside the bot.
+package permissions
+
+// Role is the level of rights in a chat; a higher role has every right of
+// the lower ones.
+type Role int
+
+const (
+	RoleMember Role = iota
+	// RoleHost may close polls and drive any queue of the chat
+	RoleHost
+	RoleAdmin
+	RoleOwner
+)
+
+var roleNames = map[Role]string{
+	RoleMember: "member",
+	RoleHost:   "host",
+	RoleAdmin:  "admin",
+	RoleOwner:  "owner",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole parses a role name as stored in the database.
+func ParseRole(s string) (Role, bool) {
+	for r, name := range roleNames {
+		if name == s {
+			return r, true
+		}
+	}
+	return RoleMember, false
+}
@@ -0,0 +1,152 @@
+// Package pollcreate posts a new poll to a chat and stores it. It is shared by
+// the interactive wizard, the one-line /poll command and recurring schedules.
+package pollcreate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/ordering"
+	"github.com/nikitkaralius/lineup/internal/polls"
+	"github.com/nikitkaralius/lineup/internal/render"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// Request describes a poll to post
+type Request struct {
+	ChatID          int64
+	Topic           string
+	Duration        time.Duration
+	MaxParticipants int
+	SessionStart    time.Time
+	SlotLength      time.Duration
+	CreatorID       int64
+	CreatorUsername string
+	CreatorName     string
+	// OptionComing and OptionNotComing are the poll answers, in this order;
+	// empty ones are "Иду" and "Не иду" translated
+	OptionComing    string
+	OptionNotComing string
+	// Location is the chat timezone used in the poll question
+	Location *time.Location
+	// Printer speaks the chat's language
+	Printer *i18n.Printer
+}
+
+// Create sends the poll, then stores it together with the job that finishes
+// it in one transaction, and announces the seed commitment. If storing fails
+// the sent poll is deleted and an error is returned.
+func Create(ctx context.Context, bot telegram.Client, store *polls.Repository, pollsService polls.Service, req Request) (*polls.TelegramPollDTO, error) {
+	secret, err := ordering.NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate seed secret: %w", err)
+	}
+
+	// Create enhanced poll question with duration and end time
+	pr := req.Printer
+	endTime := time.Now().UTC().Add(req.Duration)
+	pollQuestion := pr.T("📋 Тема: %s\n⏰ Длительность: %s\n🕐 Завершится: %s",
+		req.Topic,
+		pr.Duration(req.Duration),
+		FormatTime(endTime, req.Location))
+	if req.MaxParticipants > 0 {
+		pollQuestion += pr.T("\n👥 Мест: %d", req.MaxParticipants)
+	}
+
+	coming, notComing := req.OptionComing, req.OptionNotComing
+	if coming == "" {
+		coming = pr.T("Иду")
+	}
+	if notComing == "" {
+		notComing = pr.T("Не иду")
+	}
+	pollCfg := tgbotapi.NewPoll(req.ChatID, pollQuestion, coming, notComing)
+	pollCfg.IsAnonymous = false
+	pollCfg.AllowsMultipleAnswers = false
+	sent, err := bot.Send(pollCfg)
+	if err != nil {
+		return nil, fmt.Errorf("send poll: %w", err)
+	}
+	if sent.Poll == nil {
+		return nil, errors.New("poll send returned no poll")
+	}
+
+	p := &polls.TelegramPollDTO{
+		PollID:          sent.Poll.ID,
+		ChatID:          req.ChatID,
+		MessageID:       sent.MessageID,
+		Topic:           req.Topic,
+		CreatorID:       req.CreatorID,
+		CreatorUsername: req.CreatorUsername,
+		CreatorName:     req.CreatorName,
+		StartedAt:       time.Now().UTC(),
+		Duration:        req.Duration,
+		EndsAt:          endTime,
+		SeedSecret:      secret,
+		SeedCommitment:  ordering.Commit(secret),
+		MaxParticipants: req.MaxParticipants,
+		SessionStartAt:  req.SessionStart,
+		SlotLength:      req.SlotLength,
+	}
+	if pollsService == nil {
+		err = errors.New("no polls service")
+	} else {
+		err = store.CreatePoll(ctx, p, pollsService)
+	}
+	if err != nil {
+		// Without a stored poll nobody could ever close it, so take it back
+		if _, derr := bot.Request(tgbotapi.NewDeleteMessage(p.ChatID, p.MessageID)); derr != nil {
+			log.Printf("delete failed poll error: %v", derr)
+		}
+		return nil, fmt.Errorf("create poll: %w", err)
+	}
+	// The poll ID is only known after sending, so the buttons are added now
+	controls := tgbotapi.NewEditMessageReplyMarkup(p.ChatID, p.MessageID, ControlKeyboard(pr, p.PollID))
+	if _, err := bot.Send(controls); err != nil {
+		log.Printf("add poll controls error: %v", err)
+	}
+	announceSeedCommitment(bot, pr, p)
+	return p, nil
+}
+
+// ControlKeyboard lets the poll creator and chat admins end the poll early,
+// give it more time or cancel it.
+func ControlKeyboard(p *i18n.Printer, pollID string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.T("⏹ Завершить"), "pollctl_close:"+pollID),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("➕ 30 мин"), "pollctl_extend:"+pollID),
+			tgbotapi.NewInlineKeyboardButtonData(p.T("🗑 Отменить"), "pollctl_cancel:"+pollID),
+		),
+	)
+}
+
+// announceSeedCommitment publishes the hash of the poll secret before anyone
+// votes, so the secret revealed with the results can be checked against it.
+func announceSeedCommitment(bot telegram.Client, pr *i18n.Printer, p *polls.TelegramPollDTO) {
+	text := pr.HTML("🔐 Хэш секрета жеребьёвки: <code>%s</code>\nСекрет будет раскрыт вместе с результатами.", p.SeedCommitment)
+	msg := tgbotapi.NewMessage(p.ChatID, text)
+	msg.ParseMode = render.ParseMode
+	msg.ReplyToMessageID = p.MessageID
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("announce seed commitment error: %v", err)
+	}
+}
+
+// UserName returns the full name of a Telegram user.
+func UserName(u *tgbotapi.User) string {
+	if u.LastName != "" {
+		return u.FirstName + " " + u.LastName
+	}
+	return u.FirstName
+}
+
+// FormatTime renders t in the chat's timezone.
+func FormatTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("15:04 02.01.2006 MST")
+}
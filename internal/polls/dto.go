@@ -2,6 +2,18 @@ package polls
 
 import "time"
 
+// Poll statuses. A poll being finished goes active → stopped (voting closed,
+// lineup drawn) → posting (results message claimed) → posted (results
+// message sent and recorded) → processed.
+const (
+	PollStatusActive    = "active"
+	PollStatusStopped   = "stopped"
+	PollStatusPosting   = "posting"
+	PollStatusPosted    = "posted"
+	PollStatusProcessed = "processed"
+	PollStatusCancelled = "cancelled"
+)
+
 type TelegramPollDTO struct {
 	PollID          string
 	ChatID          int64
@@ -13,4 +25,38 @@ type TelegramPollDTO struct {
 	StartedAt       time.Time
 	Duration        time.Duration
 	EndsAt          time.Time
+	Status          string
+	// FinishJobID is the River job that finishes the poll; 0 for old polls
+	FinishJobID int64
+	// SeedSecret is revealed with the results; SeedCommitment is announced upfront
+	SeedSecret     string
+	SeedCommitment string
+	// MaxParticipants limits the main list, the rest go to the waitlist; 0 means unlimited
+	MaxParticipants int
+	// SessionStartAt and SlotLength, when set, give every queue position an estimated time
+	SessionStartAt time.Time
+	SlotLength     time.Duration
+	// CurrentStartedAt is when the host last advanced the queue
+	CurrentStartedAt time.Time
+	// ResultsMessageID is set once the lineup has been posted
+	ResultsMessageID int
+}
+
+// HasSchedule reports whether queue positions get estimated start times.
+func (p *TelegramPollDTO) HasSchedule() bool {
+	return !p.SessionStartAt.IsZero() && p.SlotLength > 0
+}
+
+// EstimatedStart returns when the person at position is expected to present,
+// given the position presenting now. Times are counted from the moment the
+// host last advanced the queue, or from the session start before that.
+func (p *TelegramPollDTO) EstimatedStart(current, position int) (time.Time, bool) {
+	if !p.HasSchedule() || position < current {
+		return time.Time{}, false
+	}
+	base := p.SessionStartAt
+	if p.CurrentStartedAt.After(base) {
+		base = p.CurrentStartedAt
+	}
+	return base.Add(time.Duration(position-current) * p.SlotLength), true
 }
@@ -0,0 +1,36 @@
+package polls
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimatedStart(t *testing.T) {
+	start := time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name              string
+		startedAt         time.Time
+		current, position int
+		want              time.Time
+		ok                bool
+	}{
+		{"first before start", time.Time{}, 1, 1, start, true},
+		{"third before start", time.Time{}, 1, 3, start.Add(20 * time.Minute), true},
+		// The host advanced late, so later times move with it
+		{"after a late advance", start.Add(25 * time.Minute), 2, 4, start.Add(45 * time.Minute), true},
+		{"advanced early", start.Add(-5 * time.Minute), 1, 2, start.Add(10 * time.Minute), true},
+		{"already presented", time.Time{}, 3, 2, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		p := &TelegramPollDTO{SessionStartAt: start, SlotLength: 10 * time.Minute, CurrentStartedAt: tt.startedAt}
+		got, ok := p.EstimatedStart(tt.current, tt.position)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("%s: EstimatedStart(%d, %d) = %s, %v, want %s, %v", tt.name, tt.current, tt.position, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	var unscheduled TelegramPollDTO
+	if _, ok := unscheduled.EstimatedStart(1, 1); ok {
+		t.Error("EstimatedStart gave a time for a poll without a schedule")
+	}
+}
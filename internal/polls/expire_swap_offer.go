@@ -0,0 +1,10 @@
+package polls
+
+// ExpireSwapOfferArgs defines the arguments for a job that closes a queue swap
+// offer nobody answered in time.
+type ExpireSwapOfferArgs struct {
+	OfferID int64 `json:"offer_id"`
+}
+
+// Kind implements river.JobArgs to identify this job type.
+func (ExpireSwapOfferArgs) Kind() string { return "expire_swap_offer" }
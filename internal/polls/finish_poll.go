@@ -4,7 +4,7 @@ package polls
 // by stopping it and posting the results.
 // This type is shared between service (for enqueue) and worker (for processing).
 type FinishPollArgs struct {
-	PollID    string `json:"poll_id"`
+	PollID    string `json:"poll_id" river:"unique"`
 	ChatID    int64  `json:"chat_id"`
 	MessageID int    `json:"message_id"`
 	Topic     string `json:"topic"`
@@ -12,3 +12,8 @@ type FinishPollArgs struct {
 
 // Kind implements river.JobArgs to identify this job type.
 func (FinishPollArgs) Kind() string { return "finish_poll" }
+
+// FinishArgs returns the arguments of the job finishing p.
+func (p *TelegramPollDTO) FinishArgs() FinishPollArgs {
+	return FinishPollArgs{PollID: p.PollID, ChatID: p.ChatID, MessageID: p.MessageID, Topic: p.Topic}
+}
@@ -0,0 +1,13 @@
+package polls
+
+// ReconcileArgs defines the arguments for the periodic job that re-enqueues
+// finish jobs for expired polls that lost theirs.
+type ReconcileArgs struct{}
+
+// Kind implements river.JobArgs to identify this job type.
+func (ReconcileArgs) Kind() string { return "reconcile_polls" }
+
+// MaxReconcileAttempts is how many times a poll gets a new finish job. A poll
+// whose jobs keep failing is left alone after that instead of being retried
+// forever.
+const MaxReconcileAttempts = 3
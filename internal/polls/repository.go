@@ -2,13 +2,22 @@ package polls
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // This AI crap will be refactored
 
+// ErrPollNotActive is returned when a poll was already finished or cancelled.
+var ErrPollNotActive = errors.New("poll is not active")
+
+// ErrPollStatusChanged is returned by Transition when another run already
+// moved the poll on.
+var ErrPollStatusChanged = errors.New("poll status changed")
+
 type Repository struct {
 	DB *pgxpool.Pool
 }
@@ -17,39 +26,107 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{DB: db}
 }
 
-func (s *Repository) InsertPoll(ctx context.Context, p *TelegramPollDTO) error {
-	_, err := s.DB.Query(ctx, `INSERT INTO polls (
-		poll_id, chat_id, message_id, topic, creator_id, creator_username, creator_name, started_at, duration_seconds, ends_at, status
-	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,'active')
-	ON CONFLICT (poll_id) DO NOTHING`,
+// CreatePoll stores the poll and enqueues its finish job in one transaction,
+// so there is never a poll without a job or a job without a poll.
+func (s *Repository) CreatePoll(ctx context.Context, p *TelegramPollDTO, service Service) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	jobID, err := service.SchedulePollFinishTx(ctx, tx, p.FinishArgs(), p.EndsAt)
+	if err != nil {
+		return err
+	}
+	p.FinishJobID = jobID
+	_, err = tx.Exec(ctx, `INSERT INTO polls (
+		poll_id, chat_id, message_id, topic, creator_id, creator_username, creator_name, started_at, duration_seconds, ends_at, status,
+		seed_secret, seed_commitment, max_participants, session_start_at, slot_seconds, finish_job_id
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,'active',$11,$12,$13,$14,$15,$16)`,
 		p.PollID, p.ChatID, p.MessageID, p.Topic, p.CreatorID, p.CreatorUsername, p.CreatorName, p.StartedAt, int(p.Duration/time.Second), p.EndsAt,
+		p.SeedSecret, p.SeedCommitment, p.MaxParticipants, nullTime(p.SessionStartAt), int(p.SlotLength/time.Second), p.FinishJobID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
+// FindExpiredActivePolls returns polls past their end that are not finished
+// yet and have no live finish job, e.g. because the job was lost or gave up.
+// Polls reconciled MaxReconcileAttempts times already are left out.
 func (s *Repository) FindExpiredActivePolls(ctx context.Context) ([]TelegramPollDTO, error) {
-	rows, err := s.DB.Query(ctx, `SELECT poll_id, chat_id, message_id, topic, ends_at FROM polls WHERE status='active' AND ends_at <= NOW()`)
+	rows, err := s.DB.Query(ctx, `SELECT `+pollColumns+` FROM polls p
+	WHERE status IN ('active','stopped','posting','posted') AND ends_at <= NOW()
+	AND reconcile_attempts < $1
+	AND NOT EXISTS (
+		SELECT 1 FROM river_job j WHERE j.id = p.finish_job_id
+		AND j.state IN ('available','pending','retryable','running','scheduled')
+	)`, MaxReconcileAttempts)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var res []TelegramPollDTO
 	for rows.Next() {
-		var p TelegramPollDTO
-		if err := rows.Scan(&p.PollID, &p.ChatID, &p.MessageID, &p.Topic, &p.EndsAt); err != nil {
+		p, err := scanPoll(rows)
+		if err != nil {
 			return nil, err
 		}
-		res = append(res, p)
+		res = append(res, *p)
 	}
 	return res, rows.Err()
 }
 
-func (s *Repository) MarkProcessed(ctx context.Context, pollID string, resultsMessageID int) error {
-	_, err := s.DB.Exec(ctx, `UPDATE polls SET status='processed', processed_at=NOW(), results_message_id=$2 WHERE poll_id=$1`, pollID, resultsMessageID)
+// Transition moves a poll from status from to status to. The poll row stays
+// locked while fn runs in the same transaction, so a step of finishing a poll
+// is applied at most once. It returns ErrPollStatusChanged if the poll is no
+// longer in status from.
+func (s *Repository) Transition(ctx context.Context, pollID, from, to string, fn func(tx pgx.Tx) error) error {
+	tx, err := s.DB.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	return err
+	defer tx.Rollback(ctx)
+
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM polls WHERE poll_id=$1 FOR UPDATE`, pollID).Scan(&status); err != nil {
+		return err
+	}
+	if status != from {
+		return ErrPollStatusChanged
+	}
+	if fn != nil {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE polls SET status=$2 WHERE poll_id=$1`, pollID, to); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetResultsMessage records the message the lineup was posted in while the
+// poll is posting, so a retry edits that message instead of sending another.
+func (s *Repository) SetResultsMessage(ctx context.Context, pollID string, resultsMessageID int) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE polls SET results_message_id=$2 WHERE poll_id=$1 AND status='posting'`, pollID, resultsMessageID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPollStatusChanged
+	}
+	return nil
+}
+
+// MarkProcessed completes a poll whose lineup has been posted.
+func (s *Repository) MarkProcessed(ctx context.Context, pollID string) error {
+	return s.Transition(ctx, pollID, PollStatusPosted, PollStatusProcessed, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE polls SET processed_at=NOW() WHERE poll_id=$1`, pollID)
+		return err
+	})
 }
 
 func (s *Repository) GetPollTopic(ctx context.Context, pollID string) (string, error) {
@@ -57,3 +134,118 @@ func (s *Repository) GetPollTopic(ctx context.Context, pollID string) (string, e
 	err := s.DB.QueryRow(ctx, `SELECT topic FROM polls WHERE poll_id=$1`, pollID).Scan(&topic)
 	return topic, err
 }
+
+func (s *Repository) GetPollCreator(ctx context.Context, pollID string) (int64, error) {
+	var creatorID int64
+	err := s.DB.QueryRow(ctx, `SELECT creator_id FROM polls WHERE poll_id=$1`, pollID).Scan(&creatorID)
+	return creatorID, err
+}
+
+// pollColumns are the columns read by scanPoll
+const pollColumns = `poll_id, chat_id, message_id, topic, creator_id, max_participants, COALESCE(results_message_id, 0),
+	session_start_at, slot_seconds, current_started_at, started_at, duration_seconds, ends_at, status, COALESCE(finish_job_id, 0)`
+
+func scanPoll(row pgx.Row) (*TelegramPollDTO, error) {
+	var p TelegramPollDTO
+	var sessionStartAt, currentStartedAt *time.Time
+	var slotSeconds, durationSeconds int
+	err := row.Scan(&p.PollID, &p.ChatID, &p.MessageID, &p.Topic, &p.CreatorID, &p.MaxParticipants, &p.ResultsMessageID,
+		&sessionStartAt, &slotSeconds, &currentStartedAt, &p.StartedAt, &durationSeconds, &p.EndsAt, &p.Status, &p.FinishJobID)
+	if err != nil {
+		return nil, err
+	}
+	if sessionStartAt != nil {
+		p.SessionStartAt = *sessionStartAt
+	}
+	if currentStartedAt != nil {
+		p.CurrentStartedAt = *currentStartedAt
+	}
+	p.SlotLength = time.Duration(slotSeconds) * time.Second
+	p.Duration = time.Duration(durationSeconds) * time.Second
+	return &p, nil
+}
+
+// FindPollByResultsMessage returns the poll whose lineup was posted as the given message.
+func (s *Repository) FindPollByResultsMessage(ctx context.Context, chatID int64, messageID int) (*TelegramPollDTO, error) {
+	return scanPoll(s.DB.QueryRow(ctx, `SELECT `+pollColumns+` FROM polls WHERE chat_id=$1 AND results_message_id=$2`, chatID, messageID))
+}
+
+// FindLatestProcessedPoll returns the most recently finished poll of the chat.
+func (s *Repository) FindLatestProcessedPoll(ctx context.Context, chatID int64) (*TelegramPollDTO, error) {
+	return scanPoll(s.DB.QueryRow(ctx, `SELECT `+pollColumns+` FROM polls
+	WHERE chat_id=$1 AND status='processed' AND results_message_id IS NOT NULL
+	ORDER BY processed_at DESC LIMIT 1`, chatID))
+}
+
+// FindPollByMessage returns the poll posted as the given message.
+func (s *Repository) FindPollByMessage(ctx context.Context, chatID int64, messageID int) (*TelegramPollDTO, error) {
+	return scanPoll(s.DB.QueryRow(ctx, `SELECT `+pollColumns+` FROM polls WHERE chat_id=$1 AND message_id=$2`, chatID, messageID))
+}
+
+// FindLatestActivePoll returns the most recently started poll of the chat
+// that is still collecting votes.
+func (s *Repository) FindLatestActivePoll(ctx context.Context, chatID int64) (*TelegramPollDTO, error) {
+	return scanPoll(s.DB.QueryRow(ctx, `SELECT `+pollColumns+` FROM polls
+	WHERE chat_id=$1 AND status='active' ORDER BY started_at DESC LIMIT 1`, chatID))
+}
+
+func (s *Repository) GetPoll(ctx context.Context, pollID string) (*TelegramPollDTO, error) {
+	return scanPoll(s.DB.QueryRow(ctx, `SELECT `+pollColumns+` FROM polls WHERE poll_id=$1`, pollID))
+}
+
+// GetSeedSecret returns the secret and its announced commitment. Both are
+// empty for polls created before seeds were introduced.
+func (s *Repository) GetSeedSecret(ctx context.Context, pollID string) (string, string, error) {
+	var secret, commitment string
+	err := s.DB.QueryRow(ctx, `SELECT COALESCE(seed_secret,''), COALESCE(seed_commitment,'') FROM polls WHERE poll_id=$1`, pollID).Scan(&secret, &commitment)
+	return secret, commitment, err
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (s *Repository) SetFinishJobID(ctx context.Context, pollID string, jobID int64) error {
+	_, err := s.DB.Exec(ctx, `UPDATE polls SET finish_job_id=$2 WHERE poll_id=$1`, pollID, jobID)
+	return err
+}
+
+// SetReconciledJob records a finish job enqueued by the reconciler and counts
+// the attempt, returning how many there have been.
+func (s *Repository) SetReconciledJob(ctx context.Context, pollID string, jobID int64) (int, error) {
+	var attempts int
+	err := s.DB.QueryRow(ctx, `UPDATE polls SET finish_job_id=$2, reconcile_attempts=reconcile_attempts+1
+	WHERE poll_id=$1 RETURNING reconcile_attempts`, pollID, jobID).Scan(&attempts)
+	return attempts, err
+}
+
+// UpdateEndsAt moves the end of an active poll. It returns ErrPollNotActive
+// if the poll was already finished or cancelled.
+func (s *Repository) UpdateEndsAt(ctx context.Context, pollID string, endsAt time.Time) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE polls SET ends_at=$2, duration_seconds=EXTRACT(EPOCH FROM ($2 - started_at))::INT
+	WHERE poll_id=$1 AND status='active'`, pollID, endsAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPollNotActive
+	}
+	return nil
+}
+
+// CancelPoll marks an active poll cancelled, so no lineup is posted for it.
+// It returns ErrPollNotActive if the poll was already finished or cancelled.
+func (s *Repository) CancelPoll(ctx context.Context, pollID string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE polls SET status='cancelled', processed_at=NOW() WHERE poll_id=$1 AND status='active'`, pollID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPollNotActive
+	}
+	return nil
+}
@@ -5,27 +5,83 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/riverqueue/river"
 )
 
+// finishPollMaxAttempts bounds retries of a failing finish job; the worker
+// backs off between them.
+const finishPollMaxAttempts = 10
+
 type Service interface {
-	SchedulePollFinish(ctx context.Context, args FinishPollArgs, runAt time.Time) error
+	// SchedulePollFinish enqueues the job finishing a poll and returns its ID.
+	// If the poll already has a pending job, that job's ID is returned
+	SchedulePollFinish(ctx context.Context, args FinishPollArgs, runAt time.Time) (int64, error)
+	// SchedulePollFinishTx is SchedulePollFinish inside tx; the job only
+	// exists if tx commits
+	SchedulePollFinishTx(ctx context.Context, tx pgx.Tx, args FinishPollArgs, runAt time.Time) (int64, error)
+	// FinishPollNow makes a scheduled finish job run right away
+	FinishPollNow(ctx context.Context, jobID int64) error
+	// CancelPollFinish cancels a scheduled finish job
+	CancelPollFinish(ctx context.Context, jobID int64) error
+	ScheduleSwapOfferExpiry(ctx context.Context, args ExpireSwapOfferArgs, runAt time.Time) error
+}
+
+type pollService struct {
+	client *river.Client[pgx.Tx]
+}
+
+func NewPollsService(client *river.Client[pgx.Tx]) Service {
+	return &pollService{client: client}
+}
+
+func (r *pollService) SchedulePollFinish(ctx context.Context, args FinishPollArgs, runAt time.Time) (int64, error) {
+	if runAt.IsZero() {
+		return 0, fmt.Errorf("runAt must be non zero")
+	}
+	res, err := r.client.Insert(ctx, args, finishPollOpts(runAt))
+	if err != nil {
+		return 0, err
+	}
+	return res.Job.ID, nil
 }
 
-type pollService[TTx any] struct {
-	client *river.Client[TTx]
+func (r *pollService) SchedulePollFinishTx(ctx context.Context, tx pgx.Tx, args FinishPollArgs, runAt time.Time) (int64, error) {
+	if runAt.IsZero() {
+		return 0, fmt.Errorf("runAt must be non zero")
+	}
+	res, err := r.client.InsertTx(ctx, tx, args, finishPollOpts(runAt))
+	if err != nil {
+		return 0, err
+	}
+	return res.Job.ID, nil
+}
+
+// finishPollOpts allows a single pending finish job per poll; inserting
+// another one returns the existing job instead. Cancelled jobs do not count,
+// so a poll can be rescheduled after its job is cancelled.
+func finishPollOpts(runAt time.Time) *river.InsertOpts {
+	return &river.InsertOpts{
+		MaxAttempts: finishPollMaxAttempts,
+		ScheduledAt: runAt,
+		UniqueOpts:  river.UniqueOpts{ByArgs: true},
+	}
 }
 
-func NewPollsService[TTx any](client *river.Client[TTx]) Service {
-	return &pollService[TTx]{client: client}
+func (r *pollService) FinishPollNow(ctx context.Context, jobID int64) error {
+	_, err := r.client.JobRetry(ctx, jobID)
+	return err
+}
+
+func (r *pollService) CancelPollFinish(ctx context.Context, jobID int64) error {
+	_, err := r.client.JobCancel(ctx, jobID)
+	return err
 }
 
-func (r *pollService[TTx]) SchedulePollFinish(ctx context.Context, args FinishPollArgs, runAt time.Time) error {
-	opts := &river.InsertOpts{MaxAttempts: 1}
+func (r *pollService) ScheduleSwapOfferExpiry(ctx context.Context, args ExpireSwapOfferArgs, runAt time.Time) error {
 	if runAt.IsZero() {
 		return fmt.Errorf("runAt must be non zero")
 	}
-	opts.ScheduledAt = runAt
-	_, err := r.client.Insert(ctx, args, opts)
+	_, err := r.client.Insert(ctx, args, &river.InsertOpts{ScheduledAt: runAt})
 	return err
 }
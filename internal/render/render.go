@@ -0,0 +1,104 @@
+// Package render builds message texts for Telegram's HTML parse mode. Every
+// piece of user content (topics, names, usernames) must pass through it, so
+// stray '<', '&', '*' or '_' can neither break a message nor inject markup.
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ParseMode is the parse mode of every text built by this package.
+const ParseMode = tgbotapi.ModeHTML
+
+// HTML is markup that is already safe to send and is not escaped again.
+type HTML string
+
+// Escape makes s safe to embed in an HTML message.
+func Escape(s string) string {
+	return html.EscapeString(s)
+}
+
+// Bold renders s in bold.
+func Bold(s string) HTML {
+	return HTML("<b>" + Escape(s) + "</b>")
+}
+
+// Italic renders s in italics.
+func Italic(s string) HTML {
+	return HTML("<i>" + Escape(s) + "</i>")
+}
+
+// Code renders s monospaced.
+func Code(s string) HTML {
+	return HTML("<code>" + Escape(s) + "</code>")
+}
+
+// User renders a participant as "@username (Name)". Without a username the
+// name links to the user's ID instead, so they are notified all the same.
+func User(userID int64, username, name string) HTML {
+	if username == "" {
+		if userID == 0 {
+			return HTML(Escape(name))
+		}
+		return HTML(fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, userID, Escape(name)))
+	}
+	s := "@" + Escape(username)
+	if name != "" {
+		s += " (" + Escape(name) + ")"
+	}
+	return HTML(s)
+}
+
+// Sprintf formats like fmt.Sprintf with format taken as trusted markup.
+// String, Stringer and error arguments are escaped; HTML arguments and
+// numbers are inserted as they are.
+func Sprintf(format string, args ...any) string {
+	safe := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case HTML:
+			safe[i] = string(v)
+		case string:
+			safe[i] = Escape(v)
+		case error:
+			safe[i] = Escape(v.Error())
+		case fmt.Stringer:
+			safe[i] = Escape(v.String())
+		default:
+			safe[i] = arg
+		}
+	}
+	return fmt.Sprintf(format, safe...)
+}
+
+// Join escapes each element and joins them with sep.
+func Join(elems []string, sep string) string {
+	escaped := make([]string, len(elems))
+	for i, e := range elems {
+		escaped[i] = Escape(e)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// Length returns how long markup s counts against Telegram's message limit:
+// the length of the text left after parsing, in UTF-16 code units.
+func Length(s string) int {
+	var text strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			text.WriteRune(r)
+		}
+	}
+	return len(utf16.Encode([]rune(html.UnescapeString(text.String()))))
+}
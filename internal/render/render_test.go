@@ -0,0 +1,105 @@
+package render
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ivan_petrov", "ivan_petrov"},
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"A & B", "A &amp; B"},
+		{"*bold*", "*bold*"},
+		{`"quoted" 'single'`, "&#34;quoted&#34; &#39;single&#39;"},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.in); got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	tests := []struct {
+		name string
+		got  HTML
+		want HTML
+	}{
+		{"bold", Bold("A & B"), "<b>A &amp; B</b>"},
+		{"italic", Italic("<i>"), "<i>&lt;i&gt;</i>"},
+		{"code", Code("<script>alert(1)</script>"), "<code>&lt;script&gt;alert(1)&lt;/script&gt;</code>"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int64
+		username string
+		nick     string
+		want     HTML
+	}{
+		{"username only", 1, "ivan_petrov", "", "@ivan_petrov"},
+		{"username and name", 1, "ivan_petrov", "*bold*", "@ivan_petrov (*bold*)"},
+		{"hostile name", 2, "a_b", "<script>alert(1)</script>", "@a_b (&lt;script&gt;alert(1)&lt;/script&gt;)"},
+		{"link without username", 42, "", "A & B", `<a href="tg://user?id=42">A &amp; B</a>`},
+		{"no user ID", 0, "", "<b>", "&lt;b&gt;"},
+	}
+	for _, tt := range tests {
+		if got := User(tt.userID, tt.username, tt.nick); got != tt.want {
+			t.Errorf("%s: User(%d, %q, %q) = %q, want %q", tt.name, tt.userID, tt.username, tt.nick, got, tt.want)
+		}
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestSprintf(t *testing.T) {
+	got := Sprintf("<b>%s</b> %s %v %d %s %s",
+		"<script>alert(1)</script>",
+		HTML("<i>ok</i>"),
+		stringer("A & B"),
+		7,
+		errors.New("x < y"),
+		"*bold*")
+	want := "<b>&lt;script&gt;alert(1)&lt;/script&gt;</b> <i>ok</i> A &amp; B 7 x &lt; y *bold*"
+	if got != want {
+		t.Errorf("Sprintf = %q, want %q", got, want)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got := Join([]string{"A & B", "<c>", "ivan_petrov"}, ", ")
+	want := "A &amp; B, &lt;c&gt;, ivan_petrov"
+	if got != want {
+		t.Errorf("Join = %q, want %q", got, want)
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"plain", 5},
+		{"<b>A &amp; B</b>", 5},
+		{`<a href="tg://user?id=42">&lt;Анна&gt;</a>`, 6},
+		// Emoji outside the BMP take two UTF-16 units
+		{"🎯 <i>x</i>", 4},
+	}
+	for _, tt := range tests {
+		if got := Length(tt.in); got != tt.want {
+			t.Errorf("Length(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+	"github.com/nikitkaralius/lineup/internal/telegram"
+)
+
+// slowUpdate is how long handling may take before it is logged without
+// verbose logging.
+const slowUpdate = 2 * time.Second
+
+// Recover logs a panicking handler instead of crashing the service.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("panic handling update %d (%s): %v\n%s", update.UpdateID, Route(ctx), r, debug.Stack())
+				}
+			}()
+			next(ctx, update)
+		}
+	}
+}
+
+// Logging logs every routed update when verbose, and slow ones always.
+func Logging(verbose bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			start := time.Now()
+			next(ctx, update)
+			if elapsed := time.Since(start); verbose || elapsed > slowUpdate {
+				var userID int64
+				if u := sender(update); u != nil {
+					userID = u.ID
+				}
+				log.Printf("update %d %s from %d handled in %s", update.UpdateID, Route(ctx), userID, elapsed.Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// ChatTypes drops updates that do not come from a chat of one of types,
+// e.g. "group" and "supergroup".
+func ChatTypes(types ...string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			if c := chat(update); c == nil || !slices.Contains(types, c.Type) {
+				return
+			}
+			next(ctx, update)
+		}
+	}
+}
+
+// Authorize lets through only users allowed in the chat of the update;
+// others get denial, translated, as a reply or a callback alert.
+func Authorize(bot telegram.Client, allowed func(ctx context.Context, chatID, userID int64) bool, denial string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			c, u := chat(update), sender(update)
+			if c == nil || u == nil {
+				return
+			}
+			if !allowed(ctx, c.ID, u.ID) {
+				deny(bot, update, i18n.ForUser(ctx).T(denial))
+				return
+			}
+			next(ctx, update)
+		}
+	}
+}
+
+// Localize puts the languages of the update into the context: the chat's,
+// as chatLang returns it, and the sender's Telegram language, which wins for
+// texts addressed to them. See i18n.ForUser and i18n.ForChat.
+func Localize(chatLang func(ctx context.Context, chatID int64) i18n.Lang) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			lang := i18n.Default
+			if c := chat(update); c != nil {
+				lang = chatLang(ctx, c.ID)
+			}
+			var tag string
+			if u := sender(update); u != nil {
+				tag = u.LanguageCode
+			}
+			next(i18n.WithLocale(ctx, i18n.Resolve(lang, tag), lang), update)
+		}
+	}
+}
+
+// AnswerCallback answers callback queries with an empty text before the
+// handler runs, for handlers that do not report a status themselves.
+func AnswerCallback(bot telegram.Client) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			if update.CallbackQuery != nil {
+				bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
+			}
+			next(ctx, update)
+		}
+	}
+}
+
+// RateLimit allows each user rate updates per second with bursts of burst.
+// Extra button presses get a short notice; extra messages are dropped.
+func RateLimit(bot telegram.Client, rate float64, burst int) Middleware {
+	limiter := newUserLimiter(rate, burst)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *tgbotapi.Update) {
+			if u := sender(update); u != nil && !limiter.allow(u.ID) {
+				if update.CallbackQuery != nil {
+					bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, i18n.ForUser(ctx).T("⏳ Слишком часто, подождите пару секунд")))
+				}
+				return
+			}
+			next(ctx, update)
+		}
+	}
+}
+
+// deny tells the sender of update why it was refused.
+func deny(bot telegram.Client, update *tgbotapi.Update, text string) {
+	switch {
+	case update.CallbackQuery != nil:
+		bot.Request(tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, text))
+	case update.Message != nil:
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		reply.ReplyToMessageID = update.Message.MessageID
+		bot.Send(reply)
+	}
+}
+
+// chat returns the chat update happened in, if any.
+func chat(update *tgbotapi.Update) *tgbotapi.Chat {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat
+	}
+	return nil
+}
+
+// sender returns the user who caused update, if any.
+func sender(update *tgbotapi.Update) *tgbotapi.User {
+	if update.PollAnswer != nil {
+		return &update.PollAnswer.User
+	}
+	return update.SentFrom()
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// userLimiterPruneSize is how many users are tracked before idle ones are
+// dropped.
+const userLimiterPruneSize = 4096
+
+// userLimiter keeps a token bucket per user. Unlike the Telegram client
+// buckets it never waits: an update either has a token or is refused.
+type userLimiter struct {
+	mu    sync.Mutex
+	rate  float64
+	burst float64
+	users map[int64]*userTokens
+}
+
+type userTokens struct {
+	tokens float64
+	last   time.Time
+}
+
+func newUserLimiter(rate float64, burst int) *userLimiter {
+	return &userLimiter{rate: rate, burst: float64(burst), users: make(map[int64]*userTokens)}
+}
+
+// allow takes a token of userID and reports whether one was available.
+func (l *userLimiter) allow(userID int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	t, ok := l.users[userID]
+	if !ok {
+		if len(l.users) >= userLimiterPruneSize {
+			l.prune(now)
+		}
+		t = &userTokens{tokens: l.burst, last: now}
+		l.users[userID] = t
+	}
+	t.tokens = min(l.burst, t.tokens+now.Sub(t.last).Seconds()*l.rate)
+	t.last = now
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// prune forgets users whose bucket has refilled completely.
+func (l *userLimiter) prune(now time.Time) {
+	for id, t := range l.users {
+		if t.tokens+now.Sub(t.last).Seconds()*l.rate >= l.burst {
+			delete(l.users, id)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package router routes Telegram updates to handlers registered for
+// commands, callback data prefixes and update types, through a chain of
+// middleware.
+package router
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandlerFunc handles a single update.
+type HandlerFunc func(ctx context.Context, update *tgbotapi.Update)
+
+// Middleware wraps a handler, e.g. to filter or observe updates.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// UpdateType selects updates by their kind.
+type UpdateType string
+
+const (
+	// Message matches messages that are not a registered command
+	Message UpdateType = "message"
+	// CallbackQuery matches callback queries no prefix matched
+	CallbackQuery UpdateType = "callback_query"
+	PollAnswer    UpdateType = "poll_answer"
+)
+
+type callbackRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+type Router struct {
+	botUsername string
+	middleware  []Middleware
+	commands    map[string]HandlerFunc
+	callbacks   []callbackRoute
+	types       map[UpdateType]HandlerFunc
+}
+
+// New returns a router for the bot named botUsername; commands addressed to
+// other bots, like /poll@other_bot, are ignored.
+func New(botUsername string) *Router {
+	return &Router{
+		botUsername: botUsername,
+		commands:    make(map[string]HandlerFunc),
+		types:       make(map[UpdateType]HandlerFunc),
+	}
+}
+
+// Use adds middleware run for every routed update, outermost first.
+func (r *Router) Use(mw ...Middleware) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// Command registers h for /name.
+func (r *Router) Command(name string, h HandlerFunc, mw ...Middleware) {
+	r.commands[strings.ToLower(name)] = chain(h, mw)
+}
+
+// Callback registers h for callback data starting with prefix. The longest
+// matching prefix wins, so "poll_back" and "poll_back_to_topic" can coexist.
+func (r *Router) Callback(prefix string, h HandlerFunc, mw ...Middleware) {
+	r.callbacks = append(r.callbacks, callbackRoute{prefix: prefix, handler: chain(h, mw)})
+}
+
+// On registers h for updates of type t that no command or prefix matched.
+func (r *Router) On(t UpdateType, h HandlerFunc, mw ...Middleware) {
+	r.types[t] = chain(h, mw)
+}
+
+// Handle routes update to its handler, if any.
+func (r *Router) Handle(ctx context.Context, update tgbotapi.Update) {
+	route, h := r.match(&update)
+	if h == nil {
+		return
+	}
+	chain(h, r.middleware)(withRoute(ctx, route), &update)
+}
+
+func (r *Router) match(update *tgbotapi.Update) (string, HandlerFunc) {
+	switch {
+	case update.Message != nil:
+		msg := update.Message
+		if msg.IsCommand() {
+			if !r.addressedToUs(msg) {
+				return "", nil
+			}
+			name := strings.ToLower(msg.Command())
+			if h, ok := r.commands[name]; ok {
+				return "/" + name, h
+			}
+		}
+		return string(Message), r.types[Message]
+	case update.CallbackQuery != nil:
+		var best *callbackRoute
+		for i, c := range r.callbacks {
+			if strings.HasPrefix(update.CallbackQuery.Data, c.prefix) && (best == nil || len(c.prefix) > len(best.prefix)) {
+				best = &r.callbacks[i]
+			}
+		}
+		if best != nil {
+			return best.prefix, best.handler
+		}
+		return string(CallbackQuery), r.types[CallbackQuery]
+	case update.PollAnswer != nil:
+		return string(PollAnswer), r.types[PollAnswer]
+	}
+	return "", nil
+}
+
+// addressedToUs reports whether a command is for this bot: either without a
+// bot name or with ours.
+func (r *Router) addressedToUs(msg *tgbotapi.Message) bool {
+	cmd := msg.CommandWithAt()
+	at := strings.IndexByte(cmd, '@')
+	return at < 0 || strings.EqualFold(cmd[at+1:], r.botUsername)
+}
+
+// chain wraps h in mw so that mw[0] runs first.
+func chain(h HandlerFunc, mw []Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+type routeKey struct{}
+
+func withRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// Route returns the command, callback prefix or update type that matched the
+// update being handled.
+func Route(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
@@ -0,0 +1,257 @@
+package router
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nikitkaralius/lineup/internal/i18n"
+)
+
+// fakeBot records what the middleware sends instead of calling Telegram.
+type fakeBot struct {
+	mu       sync.Mutex
+	sent     []tgbotapi.Chattable
+	requests []tgbotapi.Chattable
+}
+
+func (b *fakeBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, c)
+	return tgbotapi.Message{}, nil
+}
+
+func (b *fakeBot) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests = append(b.requests, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (b *fakeBot) GetChatMember(tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	return tgbotapi.ChatMember{}, nil
+}
+
+func (b *fakeBot) GetChatAdministrators(tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error) {
+	return nil, nil
+}
+
+// answers returns the texts of the callback answers sent so far.
+func (b *fakeBot) answers() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var texts []string
+	for _, c := range b.requests {
+		if cb, ok := c.(tgbotapi.CallbackConfig); ok {
+			texts = append(texts, cb.Text)
+		}
+	}
+	return texts
+}
+
+var group = &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+
+func command(text string) tgbotapi.Update {
+	name := strings.Fields(text)[0]
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		Chat:     group,
+		From:     &tgbotapi.User{ID: 7},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(name)}},
+	}}
+}
+
+func button(data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb",
+		Data:    data,
+		From:    &tgbotapi.User{ID: 7},
+		Message: &tgbotapi.Message{Chat: group},
+	}}
+}
+
+// record returns a handler that appends name to got along with the route.
+func record(got *[]string, name string) HandlerFunc {
+	return func(ctx context.Context, update *tgbotapi.Update) {
+		*got = append(*got, name+" "+Route(ctx))
+	}
+}
+
+func TestRouting(t *testing.T) {
+	var got []string
+	r := New("lineup_bot")
+	r.Command("poll", record(&got, "poll"))
+	r.Callback("poll_back", record(&got, "back"))
+	r.Callback("poll_back_to_topic", record(&got, "back to topic"))
+	r.Callback("queue_", record(&got, "queue"))
+	r.On(Message, record(&got, "message"))
+	r.On(CallbackQuery, record(&got, "callback"))
+	r.On(PollAnswer, record(&got, "answer"))
+
+	updates := []tgbotapi.Update{
+		command("/poll Разбор 30m"),
+		command("/POLL@Lineup_Bot"),
+		command("/poll@other_bot"),
+		command("/unknown"),
+		{Message: &tgbotapi.Message{Text: "hi", Chat: group}},
+		button("poll_back"),
+		button("poll_back_to_topic"),
+		button("queue_join:1:0"),
+		button("nothing"),
+		{PollAnswer: &tgbotapi.PollAnswer{}},
+		{UpdateID: 1},
+	}
+	for _, u := range updates {
+		r.Handle(context.Background(), u)
+	}
+	want := []string{
+		"poll /poll",
+		"poll /poll",
+		// /poll@other_bot is dropped
+		"message message",
+		"message message",
+		"back poll_back",
+		"back to topic poll_back_to_topic",
+		"queue queue_",
+		"callback callback_query",
+		"answer poll_answer",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("routed\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var got []string
+	mark := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, update *tgbotapi.Update) {
+				got = append(got, name+" in")
+				next(ctx, update)
+				got = append(got, name+" out")
+			}
+		}
+	}
+	r := New("lineup_bot")
+	r.Use(mark("global 1"), mark("global 2"))
+	r.Command("poll", func(ctx context.Context, update *tgbotapi.Update) {
+		got = append(got, "handler")
+	}, mark("route 1"), mark("route 2"))
+	r.Handle(context.Background(), command("/poll"))
+
+	want := []string{
+		"global 1 in", "global 2 in", "route 1 in", "route 2 in",
+		"handler",
+		"route 2 out", "route 1 out", "global 2 out", "global 1 out",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ran\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var after bool
+	r := New("lineup_bot")
+	r.Use(Recover())
+	r.Command("boom", func(ctx context.Context, update *tgbotapi.Update) {
+		panic("boom")
+	})
+	r.Command("ok", func(ctx context.Context, update *tgbotapi.Update) {
+		after = true
+	})
+
+	r.Handle(context.Background(), command("/boom"))
+	r.Handle(context.Background(), command("/ok"))
+	if !after {
+		t.Error("the router stopped handling updates after a panic")
+	}
+}
+
+func TestChatTypes(t *testing.T) {
+	var got []string
+	r := New("lineup_bot")
+	r.Use(ChatTypes("group", "supergroup"))
+	r.On(Message, record(&got, "message"))
+
+	private := tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 7, Type: "private"}}}
+	r.Handle(context.Background(), private)
+	r.Handle(context.Background(), tgbotapi.Update{Message: &tgbotapi.Message{Chat: group}})
+	if want := []string{"message message"}; !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	bot := &fakeBot{}
+	var got []string
+	allowed := func(ctx context.Context, chatID, userID int64) bool { return userID == 1 }
+	r := New("lineup_bot")
+	r.Use(Localize(func(context.Context, int64) i18n.Lang { return i18n.Russian }))
+	r.Callback("queue_", record(&got, "queue"), Authorize(bot, allowed, "⛔ Нет прав"))
+	r.Command("poll", record(&got, "poll"), Authorize(bot, allowed, "⛔ Нет прав"))
+
+	r.Handle(context.Background(), button("queue_join"))
+	r.Handle(context.Background(), command("/poll"))
+	if len(got) != 0 {
+		t.Errorf("denied updates reached the handler: %q", got)
+	}
+	if answers := bot.answers(); !slices.Equal(answers, []string{"⛔ Нет прав"}) {
+		t.Errorf("callback answers %q, want the denial", answers)
+	}
+	if len(bot.sent) != 1 {
+		t.Errorf("sent %d replies to the denied command, want 1", len(bot.sent))
+	}
+
+	allowedButton := button("queue_join")
+	allowedButton.CallbackQuery.From = &tgbotapi.User{ID: 1}
+	r.Handle(context.Background(), allowedButton)
+	if want := []string{"queue queue_"}; !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
+
+func TestAnswerCallbackRunsFirst(t *testing.T) {
+	bot := &fakeBot{}
+	var answeredBefore bool
+	r := New("lineup_bot")
+	r.Callback("page:", func(ctx context.Context, update *tgbotapi.Update) {
+		answeredBefore = len(bot.answers()) == 1
+	}, AnswerCallback(bot))
+	r.Handle(context.Background(), button("page:1"))
+	if !answeredBefore {
+		t.Error("the callback was not answered before the handler ran")
+	}
+	if answers := bot.answers(); !slices.Equal(answers, []string{""}) {
+		t.Errorf("callback answers %q, want one empty answer", answers)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	bot := &fakeBot{}
+	handled := 0
+	r := New("lineup_bot")
+	r.Use(RateLimit(bot, 0.001, 2))
+	r.Callback("queue_", func(ctx context.Context, update *tgbotapi.Update) { handled++ })
+
+	for range 4 {
+		r.Handle(context.Background(), button("queue_join"))
+	}
+	if handled != 2 {
+		t.Errorf("handled %d presses, want the burst of 2", handled)
+	}
+	if answers := bot.answers(); len(answers) != 2 || answers[0] == "" {
+		t.Errorf("callback answers %q, want two notices", answers)
+	}
+
+	// Another user has their own bucket
+	other := button("queue_join")
+	other.CallbackQuery.From = &tgbotapi.User{ID: 8}
+	r.Handle(context.Background(), other)
+	if handled != 3 {
+		t.Errorf("another user was limited too")
+	}
+}
@@ -0,0 +1,42 @@
+package schedules
+
+import "time"
+
+// PollScheduleDTO is a weekly recurring poll of a chat.
+type PollScheduleDTO struct {
+	ID              int64
+	ChatID          int64
+	Topic           string
+	Duration        time.Duration
+	MaxParticipants int
+	Weekday         time.Weekday
+	// StartMinute is the poll start as minutes after midnight in Timezone
+	StartMinute     int
+	Timezone        string
+	CreatorID       int64
+	CreatorUsername string
+	CreatorName     string
+	Paused          bool
+	NextRunAt       time.Time
+	LastRunAt       time.Time
+}
+
+// Location returns the timezone the schedule is defined in.
+func (s *PollScheduleDTO) Location() (*time.Location, error) {
+	return time.LoadLocation(s.Timezone)
+}
+
+// NextRun returns the first occurrence of the schedule strictly after t.
+func (s *PollScheduleDTO) NextRun(t time.Time) (time.Time, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := t.In(loc)
+	days := (int(s.Weekday) - int(local.Weekday()) + 7) % 7
+	next := time.Date(local.Year(), local.Month(), local.Day()+days, s.StartMinute/60, s.StartMinute%60, 0, 0, loc)
+	if !next.After(t) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+days+7, s.StartMinute/60, s.StartMinute%60, 0, 0, loc)
+	}
+	return next.UTC(), nil
+}
@@ -0,0 +1,73 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRun(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, berlin)
+	}
+	// 2026-10-17 is a Saturday; summer time in Berlin ends on 2026-10-25 and
+	// starts on 2026-03-29
+	tests := []struct {
+		name    string
+		weekday time.Weekday
+		start   string
+		now     time.Time
+		want    time.Time
+	}{
+		{"later this week", time.Tuesday, "18:00", at(2026, 10, 17, 12, 0), at(2026, 10, 20, 18, 0)},
+		{"today, still ahead", time.Saturday, "18:00", at(2026, 10, 17, 12, 0), at(2026, 10, 17, 18, 0)},
+		{"today, already passed", time.Saturday, "18:00", at(2026, 10, 17, 19, 0), at(2026, 10, 24, 18, 0)},
+		{"exactly now", time.Saturday, "18:00", at(2026, 10, 17, 18, 0), at(2026, 10, 24, 18, 0)},
+		{"weekday passed this week", time.Thursday, "09:30", at(2026, 10, 17, 12, 0), at(2026, 10, 22, 9, 30)},
+		{"midnight", time.Sunday, "00:00", at(2026, 10, 17, 23, 59), at(2026, 10, 18, 0, 0)},
+		// The local time is kept across the change, so the UTC time moves
+		{"over the end of summer time", time.Monday, "18:00", at(2026, 10, 20, 12, 0), at(2026, 10, 26, 18, 0)},
+		{"over the start of summer time", time.Monday, "18:00", at(2026, 3, 24, 12, 0), at(2026, 3, 30, 18, 0)},
+		// 02:30 does not exist on that day and becomes 03:30 summer time
+		{"time skipped by the change", time.Sunday, "02:30", at(2026, 3, 28, 12, 0), time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, err := time.Parse("15:04", tt.start)
+			if err != nil {
+				t.Fatalf("parse start: %v", err)
+			}
+			s := &PollScheduleDTO{Weekday: tt.weekday, StartMinute: start.Hour()*60 + start.Minute(), Timezone: "Europe/Berlin"}
+			got, err := s.NextRun(tt.now)
+			if err != nil {
+				t.Fatalf("NextRun: %v", err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("NextRun(%s) = %s, want %s in UTC", tt.now, got, tt.want.UTC())
+			}
+		})
+	}
+}
+
+func TestNextRunUTCOffsets(t *testing.T) {
+	// Summer and winter runs of the same schedule are an hour apart in UTC
+	s := &PollScheduleDTO{Weekday: time.Monday, StartMinute: 18 * 60, Timezone: "Europe/Berlin"}
+	summer, _ := s.NextRun(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	winter, _ := s.NextRun(summer)
+	if summer.Hour() != 16 || winter.Hour() != 17 {
+		t.Errorf("NextRun = %s, then %s, want 16:00 and 17:00 UTC", summer, winter)
+	}
+	if winter.Sub(summer) != 7*24*time.Hour+time.Hour {
+		t.Errorf("runs are %s apart, want a week and an hour", winter.Sub(summer))
+	}
+}
+
+func TestNextRunBadTimezone(t *testing.T) {
+	s := &PollScheduleDTO{Weekday: time.Monday, Timezone: "Mars/Olympus"}
+	if _, err := s.NextRun(time.Now()); err == nil {
+		t.Error("NextRun accepted an unknown timezone")
+	}
+}
@@ -0,0 +1,147 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrScheduleNotFound is returned when the chat has no schedule with the given ID.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+func (s *Repository) CreateSchedule(ctx context.Context, p *PollScheduleDTO) (int64, error) {
+	var id int64
+	err := s.DB.QueryRow(ctx, `INSERT INTO poll_schedules (
+		chat_id, topic, duration_seconds, max_participants, weekday, start_minute, timezone,
+		creator_id, creator_username, creator_name, next_run_at, created_at
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,NOW()) RETURNING id`,
+		p.ChatID, p.Topic, int(p.Duration/time.Second), p.MaxParticipants, int(p.Weekday), p.StartMinute, p.Timezone,
+		p.CreatorID, p.CreatorUsername, p.CreatorName, p.NextRunAt,
+	).Scan(&id)
+	return id, err
+}
+
+// scheduleColumns are the columns read by scanSchedule
+const scheduleColumns = `id, chat_id, topic, duration_seconds, max_participants, weekday, start_minute, timezone,
+	creator_id, COALESCE(creator_username,''), COALESCE(creator_name,''), paused, next_run_at, last_run_at`
+
+func scanSchedule(row pgx.Row) (*PollScheduleDTO, error) {
+	var p PollScheduleDTO
+	var durationSeconds, weekday int
+	var lastRunAt *time.Time
+	err := row.Scan(&p.ID, &p.ChatID, &p.Topic, &durationSeconds, &p.MaxParticipants, &weekday, &p.StartMinute, &p.Timezone,
+		&p.CreatorID, &p.CreatorUsername, &p.CreatorName, &p.Paused, &p.NextRunAt, &lastRunAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Duration = time.Duration(durationSeconds) * time.Second
+	p.Weekday = time.Weekday(weekday)
+	if lastRunAt != nil {
+		p.LastRunAt = *lastRunAt
+	}
+	return &p, nil
+}
+
+func (s *Repository) ListSchedules(ctx context.Context, chatID int64) ([]PollScheduleDTO, error) {
+	rows, err := s.DB.Query(ctx, `SELECT `+scheduleColumns+` FROM poll_schedules WHERE chat_id=$1 ORDER BY id`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []PollScheduleDTO
+	for rows.Next() {
+		p, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *p)
+	}
+	return res, rows.Err()
+}
+
+func (s *Repository) GetSchedule(ctx context.Context, chatID int64, id int64) (*PollScheduleDTO, error) {
+	p, err := scanSchedule(s.DB.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM poll_schedules WHERE chat_id=$1 AND id=$2`, chatID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	return p, err
+}
+
+// SetPaused pauses or resumes a schedule. nextRunAt is stored as well so a
+// resumed schedule does not fire for the weeks it was paused.
+func (s *Repository) SetPaused(ctx context.Context, chatID int64, id int64, paused bool, nextRunAt time.Time) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE poll_schedules SET paused=$3, next_run_at=$4 WHERE chat_id=$1 AND id=$2`, chatID, id, paused, nextRunAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+func (s *Repository) DeleteSchedule(ctx context.Context, chatID int64, id int64) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM poll_schedules WHERE chat_id=$1 AND id=$2`, chatID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// ClaimDueSchedules returns the active schedules due at now and moves each of
+// them to its next occurrence in the same transaction, so every occurrence is
+// claimed once even with several workers running.
+func (s *Repository) ClaimDueSchedules(ctx context.Context, now time.Time) ([]PollScheduleDTO, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT `+scheduleColumns+` FROM poll_schedules
+	WHERE NOT paused AND next_run_at <= $1 ORDER BY next_run_at FOR UPDATE SKIP LOCKED`, now)
+	if err != nil {
+		return nil, err
+	}
+	var due []PollScheduleDTO
+	for rows.Next() {
+		p, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, *p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range due {
+		next, err := p.NextRun(now)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE poll_schedules SET next_run_at=$2, last_run_at=$3 WHERE id=$1`, p.ID, next, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return due, nil
+}
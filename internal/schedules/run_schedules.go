@@ -0,0 +1,8 @@
+package schedules
+
+// RunSchedulesArgs defines the arguments for the periodic job that posts the
+// polls of all due schedules.
+type RunSchedulesArgs struct{}
+
+// Kind implements river.JobArgs to identify this job type.
+func (RunSchedulesArgs) Kind() string { return "run_poll_schedules" }
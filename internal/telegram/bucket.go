@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket refilled at rate tokens per second up to burst.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// until blocks the bucket after a flood limit error
+	until time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a token is available and takes it.
+func (b *bucket) wait() {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	// Take the token now, possibly going negative, and sleep off the debt
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if b.until.After(now.Add(delay)) {
+		delay = b.until.Sub(now)
+	}
+	b.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// pause makes wait block for at least d.
+func (b *bucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.until) {
+		b.until = until
+	}
+}
+
+// idle reports whether the bucket is full and not paused, i.e. forgetting it
+// changes nothing.
+func (b *bucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst && now.After(b.until)
+}
+
+// chatBucketsPruneSize is how many chats are tracked before idle ones are
+// dropped.
+const chatBucketsPruneSize = 1024
+
+// chatBuckets keeps one bucket per chat.
+type chatBuckets struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[int64]*bucket
+}
+
+func newChatBuckets(rate float64, burst int) *chatBuckets {
+	return &chatBuckets{rate: rate, burst: burst, buckets: make(map[int64]*bucket)}
+}
+
+func (c *chatBuckets) get(chatID int64) *bucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.buckets[chatID]; ok {
+		return b
+	}
+	if len(c.buckets) >= chatBucketsPruneSize {
+		now := time.Now()
+		for id, b := range c.buckets {
+			if b.idle(now) {
+				delete(c.buckets, id)
+			}
+		}
+	}
+	b := newBucket(c.rate, c.burst)
+	c.buckets[chatID] = b
+	return b
+}
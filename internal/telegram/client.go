@@ -0,0 +1,231 @@
+// Package telegram wraps the Bot API client with rate limiting and retries.
+package telegram
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client is the part of the Bot API used by handlers and workers.
+type Client interface {
+	// Send sends c and returns the resulting message
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	// Request sends c when only success matters, e.g. callback answers
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+	GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error)
+}
+
+// Limits configures the token buckets of a RateLimitedClient.
+type Limits struct {
+	// Global bounds all requests of the bot
+	GlobalRate  float64
+	GlobalBurst int
+	// PerChat bounds requests to a single chat
+	PerChatRate  float64
+	PerChatBurst int
+	// MaxRetries is how many times a rate limited or transient failure is retried
+	MaxRetries int
+	// MaxRetryAfter is the longest retry_after honoured; longer waits fail
+	MaxRetryAfter time.Duration
+}
+
+// DefaultLimits follow the Bot API guidance of about 30 requests per second
+// overall and one message per second in a chat, with small bursts for
+// several people pressing buttons at once.
+var DefaultLimits = Limits{
+	GlobalRate:    30,
+	GlobalBurst:   30,
+	PerChatRate:   1,
+	PerChatBurst:  3,
+	MaxRetries:    3,
+	MaxRetryAfter: time.Minute,
+}
+
+// transientBackoff is the wait before the first retry of a network or server
+// error; it doubles with every attempt.
+const transientBackoff = 500 * time.Millisecond
+
+// RateLimitedClient is a Client that waits for per-chat and global tokens
+// before every request, retries after "Too Many Requests" and transient
+// failures, and treats "message is not modified" as success. Requests that
+// post a new message are only retried when they surely did not arrive, so a
+// lost response never posts the message twice.
+type RateLimitedClient struct {
+	api    *tgbotapi.BotAPI
+	limits Limits
+	global *bucket
+	chats  *chatBuckets
+}
+
+func NewRateLimitedClient(api *tgbotapi.BotAPI, limits Limits) *RateLimitedClient {
+	return &RateLimitedClient{
+		api:    api,
+		limits: limits,
+		global: newBucket(limits.GlobalRate, limits.GlobalBurst),
+		chats:  newChatBuckets(limits.PerChatRate, limits.PerChatBurst),
+	}
+}
+
+func (c *RateLimitedClient) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var msg tgbotapi.Message
+	err := c.do(chattable, func() error {
+		var err error
+		msg, err = c.api.Send(chattable)
+		return err
+	})
+	return msg, err
+}
+
+func (c *RateLimitedClient) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	var resp *tgbotapi.APIResponse
+	err := c.do(chattable, func() error {
+		var err error
+		resp, err = c.api.Request(chattable)
+		return err
+	})
+	if err == nil && (resp == nil || !resp.Ok) {
+		// "message is not modified"
+		resp = &tgbotapi.APIResponse{Ok: true}
+	}
+	return resp, err
+}
+
+func (c *RateLimitedClient) GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	var member tgbotapi.ChatMember
+	err := c.do(nil, func() error {
+		var err error
+		member, err = c.api.GetChatMember(config)
+		return err
+	})
+	return member, err
+}
+
+func (c *RateLimitedClient) GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error) {
+	var members []tgbotapi.ChatMember
+	err := c.do(nil, func() error {
+		var err error
+		members, err = c.api.GetChatAdministrators(config)
+		return err
+	})
+	return members, err
+}
+
+// do runs call, which makes request c, once tokens are available, retrying
+// it as allowed by limits. c is nil for reads that are not chat bound.
+func (c *RateLimitedClient) do(req tgbotapi.Chattable, call func() error) error {
+	chatID, repeatable := chatID(req), !postsMessage(req)
+	for attempt := 0; ; attempt++ {
+		var chat *bucket
+		if chatID != 0 {
+			chat = c.chats.get(chatID)
+			chat.wait()
+		}
+		c.global.wait()
+
+		err := call()
+		if err == nil || IsNotModified(err) {
+			return nil
+		}
+		wait, retry := c.retryDelay(err, attempt, repeatable)
+		if !retry {
+			return err
+		}
+		if attempt >= c.limits.MaxRetries {
+			log.Printf("telegram: giving up after %d attempts: %v", attempt+1, err)
+			return err
+		}
+		if chat != nil && IsTooManyRequests(err) {
+			// Hold back everyone else writing to this chat too
+			chat.pause(wait)
+		}
+		time.Sleep(wait)
+	}
+}
+
+// retryDelay reports whether err is worth retrying and how long to wait first.
+// A request that is not repeatable is retried only after errors that mean it
+// never reached Telegram or was refused: a failed connection or a flood limit.
+func (c *RateLimitedClient) retryDelay(err error, attempt int, repeatable bool) (time.Duration, bool) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		// Network failure; after a timeout or a dropped connection the
+		// request may have been handled without us getting the response
+		if !repeatable && !notDelivered(err) {
+			return 0, false
+		}
+		return transientBackoff << attempt, true
+	}
+	switch {
+	case tgErr.Code == 429:
+		wait := time.Duration(tgErr.RetryAfter) * time.Second
+		if wait > c.limits.MaxRetryAfter {
+			return 0, false
+		}
+		return wait, true
+	case tgErr.Code >= 500 && repeatable:
+		return transientBackoff << attempt, true
+	}
+	return 0, false
+}
+
+// notDelivered reports whether err happened before a request was sent: the
+// address did not resolve or the connection could not be opened.
+func notDelivered(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// postsMessage reports whether c posts a new message, which a repeated
+// request would post again. Edits, deletions and callback answers can be
+// repeated safely.
+func postsMessage(c tgbotapi.Chattable) bool {
+	switch c.(type) {
+	case tgbotapi.MessageConfig, tgbotapi.SendPollConfig, tgbotapi.ForwardConfig, tgbotapi.CopyMessageConfig:
+		return true
+	}
+	return false
+}
+
+// IsNotModified reports whether err says an edit left the message unchanged.
+func IsNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message is not modified")
+}
+
+// IsCantParseEntities reports whether err says the message markup is invalid.
+func IsCantParseEntities(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "can't parse entities")
+}
+
+// IsTooManyRequests reports whether err is a Bot API flood limit error.
+func IsTooManyRequests(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == 429
+}
+
+// chatID returns the chat a request goes to, or 0 if it is not chat bound.
+func chatID(c tgbotapi.Chattable) int64 {
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		return v.ChatID
+	case tgbotapi.SendPollConfig:
+		return v.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return v.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return v.ChatID
+	case tgbotapi.StopPollConfig:
+		return v.ChatID
+	case tgbotapi.DeleteMessageConfig:
+		return v.ChatID
+	case tgbotapi.PinChatMessageConfig:
+		return v.ChatID
+	}
+	return 0
+}
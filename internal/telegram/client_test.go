@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRetryDelay(t *testing.T) {
+	c := &RateLimitedClient{limits: DefaultLimits}
+	// Errors as net/http returns them from the Bot API client
+	dial := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	dns := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.DNSError{Err: "no such host", Name: "api.telegram.org"}}
+	reset := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}}
+	timeout := &url.Error{Op: "Post", URL: "https://api.telegram.org", Err: errors.New("context deadline exceeded")}
+
+	tests := []struct {
+		name       string
+		err        error
+		repeatable bool
+		wait       time.Duration
+		retry      bool
+	}{
+		{"edit, dial failure", dial, true, transientBackoff, true},
+		{"edit, connection reset", reset, true, transientBackoff, true},
+		{"edit, timeout", timeout, true, transientBackoff, true},
+		{"edit, server error", &tgbotapi.Error{Code: 502}, true, transientBackoff, true},
+		{"edit, flood limit", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}, true, 3 * time.Second, true},
+		{"edit, bad request", &tgbotapi.Error{Code: 400}, true, 0, false},
+
+		{"send, dial failure", dial, false, transientBackoff, true},
+		{"send, unresolved host", dns, false, transientBackoff, true},
+		{"send, flood limit", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}, false, 3 * time.Second, true},
+		// The message may have been posted already
+		{"send, connection reset", reset, false, 0, false},
+		{"send, timeout", timeout, false, 0, false},
+		{"send, server error", &tgbotapi.Error{Code: 502}, false, 0, false},
+		{"send, bad request", &tgbotapi.Error{Code: 400}, false, 0, false},
+
+		{"flood limit too long", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3600}}, true, 0, false},
+	}
+	for _, tt := range tests {
+		wait, retry := c.retryDelay(tt.err, 0, tt.repeatable)
+		if wait != tt.wait || retry != tt.retry {
+			t.Errorf("%s: retryDelay = %s, %v, want %s, %v", tt.name, wait, retry, tt.wait, tt.retry)
+		}
+	}
+}
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	c := &RateLimitedClient{limits: DefaultLimits}
+	err := &tgbotapi.Error{Code: 500}
+	for attempt, want := range []time.Duration{transientBackoff, 2 * transientBackoff, 4 * transientBackoff} {
+		if wait, _ := c.retryDelay(err, attempt, true); wait != want {
+			t.Errorf("attempt %d: wait %s, want %s", attempt, wait, want)
+		}
+	}
+}
+
+func TestPostsMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		c    tgbotapi.Chattable
+		want bool
+	}{
+		{"message", tgbotapi.NewMessage(1, "hi"), true},
+		{"poll", tgbotapi.NewPoll(1, "?", "a", "b"), true},
+		{"edit", tgbotapi.NewEditMessageText(1, 2, "hi"), false},
+		{"stop poll", tgbotapi.NewStopPoll(1, 2), false},
+		{"delete", tgbotapi.NewDeleteMessage(1, 2), false},
+		{"callback answer", tgbotapi.NewCallback("id", ""), false},
+		{"no request", nil, false},
+	}
+	for _, tt := range tests {
+		if got := postsMessage(tt.c); got != tt.want {
+			t.Errorf("%s: postsMessage = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SecretTokenHeader carries the webhook secret in every update request.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// SetWebhook registers url as the webhook; Telegram then sends secret in
+// SecretTokenHeader. tgbotapi.WebhookConfig has no secret_token field, so the
+// request is built by hand.
+func SetWebhook(api *tgbotapi.BotAPI, url, secret string) error {
+	params := tgbotapi.Params{"url": url}
+	params.AddNonEmpty("secret_token", secret)
+	_, err := api.MakeRequest("setWebhook", params)
+	return err
+}
+
+// VerifySecretToken reports whether r carries the expected webhook secret.
+func VerifySecretToken(r *http.Request, secret string) bool {
+	got := r.Header.Get(SecretTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
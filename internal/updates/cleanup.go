@@ -0,0 +1,8 @@
+package updates
+
+// CleanupArgs defines the arguments for the periodic job that forgets update
+// IDs older than Retention.
+type CleanupArgs struct{}
+
+// Kind implements river.JobArgs to identify this job type.
+func (CleanupArgs) Kind() string { return "cleanup_processed_updates" }
@@ -0,0 +1,41 @@
+// Package updates remembers which Telegram updates were already handled, so
+// redelivered updates are ignored.
+package updates
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Retention is how long update IDs are kept. Telegram stops redelivering an
+// update after 24 hours.
+const Retention = 48 * time.Hour
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// MarkSeen records updateID and reports whether it is seen for the first time.
+func (s *Repository) MarkSeen(ctx context.Context, updateID int) (bool, error) {
+	tag, err := s.DB.Exec(ctx, `INSERT INTO processed_updates (update_id) VALUES ($1) ON CONFLICT DO NOTHING`, updateID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteOlderThan removes update IDs recorded before t and returns how many
+// were removed.
+func (s *Repository) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM processed_updates WHERE received_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
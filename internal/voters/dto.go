@@ -1,7 +1,55 @@
 package voters
 
+import "time"
+
 type TelegramVoterDTO struct {
 	UserID   int64
 	Username string
 	Name     string
 }
+
+// Queue entry statuses
+const (
+	QueueStatusWaiting = "waiting"
+	QueueStatusDone    = "done"
+	QueueStatusSkipped = "skipped"
+)
+
+type QueueEntryDTO struct {
+	TelegramVoterDTO
+	Position int
+	Status   string
+}
+
+// Swap offer statuses
+const (
+	SwapStatusPending  = "pending"
+	SwapStatusAccepted = "accepted"
+	SwapStatusDeclined = "declined"
+	SwapStatusExpired  = "expired"
+)
+
+type SwapOfferDTO struct {
+	ID         int64
+	PollID     string
+	ChatID     int64
+	MessageID  int
+	FromUserID int64
+	ToUserID   int64
+	Status     string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// PollResultDTO is the published lineup together with everything needed to
+// recompute it.
+type PollResultDTO struct {
+	PollID        string
+	ResultsText   string
+	Seed          int64
+	SeedSecret    string
+	Strategy      string
+	InputUserIDs  []int64
+	Weights       []float64
+	LineupUserIDs []int64
+}
@@ -2,13 +2,22 @@ package voters
 
 import (
 	"context"
+	"errors"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // This AI crap will be refactored
 
+// ErrQueueFinished is returned when the queue cursor is past the last entry.
+var ErrQueueFinished = errors.New("queue is finished")
+
+// ErrQueueExists is returned by SaveQueue when the poll already has a queue.
+var ErrQueueExists = errors.New("queue already saved")
+
 type Repository struct {
 	DB *pgxpool.Pool
 }
@@ -48,8 +57,249 @@ func (s *Repository) GetComingVoters(ctx context.Context, pollID string) ([]Tele
 	return vs, rows.Err()
 }
 
-func (s *Repository) InsertPollResult(ctx context.Context, pollID string, resultsText string) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO poll_results (poll_id, results_text, created_at) VALUES ($1,$2,NOW()) ON CONFLICT (poll_id) DO NOTHING`, pollID, resultsText)
+func (s *Repository) InsertPollResult(ctx context.Context, r *PollResultDTO) error {
+	return insertPollResult(ctx, s.DB, r)
+}
+
+// InsertPollResultTx is InsertPollResult inside tx.
+func (s *Repository) InsertPollResultTx(ctx context.Context, tx pgx.Tx, r *PollResultDTO) error {
+	return insertPollResult(ctx, tx, r)
+}
+
+func insertPollResult(ctx context.Context, db execer, r *PollResultDTO) error {
+	_, err := db.Exec(ctx, `INSERT INTO poll_results (
+		poll_id, results_text, created_at, seed, seed_secret, strategy, input_user_ids, weights, lineup_user_ids
+	) VALUES ($1,$2,NOW(),$3,$4,$5,$6,$7,$8) ON CONFLICT (poll_id) DO NOTHING`,
+		r.PollID, r.ResultsText, r.Seed, r.SeedSecret, r.Strategy, r.InputUserIDs, r.Weights, r.LineupUserIDs,
+	)
+	return err
+}
+
+// SetResultsTextTx stores the text of the posted lineup.
+func (s *Repository) SetResultsTextTx(ctx context.Context, tx pgx.Tx, pollID, text string) error {
+	_, err := tx.Exec(ctx, `UPDATE poll_results SET results_text=$2 WHERE poll_id=$1`, pollID, text)
+	return err
+}
+
+func (s *Repository) GetPollResult(ctx context.Context, pollID string) (*PollResultDTO, error) {
+	var r PollResultDTO
+	err := s.DB.QueryRow(ctx, `SELECT poll_id, results_text, COALESCE(seed, 0), COALESCE(seed_secret,''), COALESCE(strategy,''),
+		COALESCE(input_user_ids, '{}'), weights, COALESCE(lineup_user_ids, '{}')
+	FROM poll_results WHERE poll_id=$1`, pollID).
+		Scan(&r.PollID, &r.ResultsText, &r.Seed, &r.SeedSecret, &r.Strategy, &r.InputUserIDs, &r.Weights, &r.LineupUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SaveQueue stores the initial lineup order for a poll. Voters are numbered
+// starting from 1 in the order given. It returns ErrQueueExists if the poll
+// already has queue entries, rather than mixing them into the drawn order.
+func (s *Repository) SaveQueue(ctx context.Context, pollID string, vs []TelegramVoterDTO) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.SaveQueueTx(ctx, tx, pollID, vs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SaveQueueTx is SaveQueue inside tx.
+func (s *Repository) SaveQueueTx(ctx context.Context, tx pgx.Tx, pollID string, vs []TelegramVoterDTO) error {
+	if err := lockQueue(ctx, tx, pollID); err != nil {
+		return err
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM queue_entries WHERE poll_id=$1)`, pollID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrQueueExists
+	}
+	for i, v := range vs {
+		_, err := tx.Exec(ctx, `INSERT INTO queue_entries (poll_id, user_id, position, joined_at) VALUES ($1,$2,$3,NOW())
+		ON CONFLICT (poll_id, user_id) WHERE left_at IS NULL DO NOTHING`, pollID, v.UserID, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendToQueue puts the user at the end of the poll queue. It does nothing if
+// the user is already queued.
+func (s *Repository) AppendToQueue(ctx context.Context, pollID string, userID int64) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockQueue(ctx, tx, pollID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO queue_entries (poll_id, user_id, position, joined_at)
+	SELECT $1, $2, COALESCE(MAX(position), 0) + 1, NOW() FROM queue_entries WHERE poll_id=$1 AND left_at IS NULL
+	ON CONFLICT (poll_id, user_id) WHERE left_at IS NULL DO NOTHING`, pollID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveFromQueue marks the user as gone and moves everyone behind them one
+// position forward, together with the current presenter cursor. It returns the
+// position the user had, or 0 if they were not queued.
+func (s *Repository) RemoveFromQueue(ctx context.Context, pollID string, userID int64) (int, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockQueue(ctx, tx, pollID); err != nil {
+		return 0, err
+	}
+	var position int
+	err = tx.QueryRow(ctx, `UPDATE queue_entries SET left_at=NOW() WHERE poll_id=$1 AND user_id=$2 AND left_at IS NULL RETURNING position`, pollID, userID).Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, tx.Commit(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE queue_entries SET position=position-1 WHERE poll_id=$1 AND left_at IS NULL AND position > $2`, pollID, position)
+	if err != nil {
+		return 0, err
+	}
+	// Keep the cursor on the same person when someone ahead of them leaves
+	_, err = tx.Exec(ctx, `UPDATE polls SET current_position=current_position-1 WHERE poll_id=$1 AND current_position > $2`, pollID, position)
+	if err != nil {
+		return 0, err
+	}
+	return position, tx.Commit(ctx)
+}
+
+// GetQueue returns the users currently in the poll queue ordered by position.
+func (s *Repository) GetQueue(ctx context.Context, pollID string) ([]QueueEntryDTO, error) {
+	rows, err := s.DB.Query(ctx, `SELECT q.user_id, COALESCE(v.username,''), COALESCE(v.name,''), q.position, q.status
+	FROM queue_entries q
+	LEFT JOIN poll_votes v ON v.poll_id = q.poll_id AND v.user_id = q.user_id
+	WHERE q.poll_id=$1 AND q.left_at IS NULL
+	ORDER BY q.position ASC`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var es []QueueEntryDTO
+	for rows.Next() {
+		var e QueueEntryDTO
+		if err := rows.Scan(&e.UserID, &e.Username, &e.Name, &e.Position, &e.Status); err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+	}
+	return es, rows.Err()
+}
+
+// GetCurrentPosition returns the queue position of the person presenting now.
+func (s *Repository) GetCurrentPosition(ctx context.Context, pollID string) (int, error) {
+	var position int
+	err := s.DB.QueryRow(ctx, `SELECT current_position FROM polls WHERE poll_id=$1`, pollID).Scan(&position)
+	return position, err
+}
+
+// AdvanceQueue marks the current presenter with the given status and moves the
+// cursor to the next position. It returns the new current entry, or nil when
+// the end of the queue has been reached. ErrQueueFinished is returned if there
+// was nobody to advance from.
+func (s *Repository) AdvanceQueue(ctx context.Context, pollID string, status string) (*QueueEntryDTO, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockQueue(ctx, tx, pollID); err != nil {
+		return nil, err
+	}
+	var position int
+	if err := tx.QueryRow(ctx, `SELECT current_position FROM polls WHERE poll_id=$1`, pollID).Scan(&position); err != nil {
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, `UPDATE queue_entries SET status=$3 WHERE poll_id=$1 AND left_at IS NULL AND position=$2`, pollID, position, status)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrQueueFinished
+	}
+	if _, err := tx.Exec(ctx, `UPDATE polls SET current_position=$2, current_started_at=NOW() WHERE poll_id=$1`, pollID, position+1); err != nil {
+		return nil, err
+	}
+
+	var next QueueEntryDTO
+	err = tx.QueryRow(ctx, `SELECT q.user_id, COALESCE(v.username,''), COALESCE(v.name,''), q.position, q.status
+	FROM queue_entries q
+	LEFT JOIN poll_votes v ON v.poll_id = q.poll_id AND v.user_id = q.user_id
+	WHERE q.poll_id=$1 AND q.left_at IS NULL AND q.position=$2`, pollID, position+1).
+		Scan(&next.UserID, &next.Username, &next.Name, &next.Position, &next.Status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, tx.Commit(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next, tx.Commit(ctx)
+}
+
+// GetPlacementHistory returns, for each of the given users, their average
+// relative position (0 = first, 1 = last) across the latest finished lineups
+// of the same chat and topic. Users without history are left out.
+func (s *Repository) GetPlacementHistory(ctx context.Context, chatID int64, topic string, excludePollID string, userIDs []int64, limit int) (map[int64]float64, error) {
+	rows, err := s.DB.Query(ctx, `WITH recent AS (
+		SELECT poll_id FROM polls
+		WHERE chat_id=$1 AND topic=$2 AND status='processed' AND poll_id<>$3
+		ORDER BY processed_at DESC LIMIT $5
+	), sizes AS (
+		SELECT poll_id, COUNT(*) AS n FROM queue_entries
+		WHERE poll_id IN (SELECT poll_id FROM recent) AND left_at IS NULL
+		GROUP BY poll_id
+	)
+	SELECT q.user_id, AVG((q.position - 1)::FLOAT8 / GREATEST(sz.n - 1, 1))
+	FROM queue_entries q
+	JOIN sizes sz ON sz.poll_id = q.poll_id
+	WHERE q.left_at IS NULL AND q.user_id = ANY($4)
+	GROUP BY q.user_id`, chatID, topic, excludePollID, userIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[int64]float64)
+	for rows.Next() {
+		var userID int64
+		var placement float64
+		if err := rows.Scan(&userID, &placement); err != nil {
+			return nil, err
+		}
+		res[userID] = placement
+	}
+	return res, rows.Err()
+}
+
+// execer is satisfied by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// lockQueue serializes queue changes of a single poll by locking its row.
+func lockQueue(ctx context.Context, tx pgx.Tx, pollID string) error {
+	_, err := tx.Exec(ctx, `SELECT 1 FROM polls WHERE poll_id=$1 FOR UPDATE`, pollID)
 	return err
 }
 
@@ -0,0 +1,116 @@
+package voters
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrSwapOfferClosed is returned when an offer was already answered or expired.
+	ErrSwapOfferClosed = errors.New("swap offer is closed")
+	// ErrSwapNotPossible is returned when one of the users is no longer waiting in the queue.
+	ErrSwapNotPossible = errors.New("swap is not possible")
+)
+
+func (s *Repository) CreateSwapOffer(ctx context.Context, o *SwapOfferDTO) (int64, error) {
+	var id int64
+	err := s.DB.QueryRow(ctx, `INSERT INTO swap_offers (poll_id, chat_id, from_user_id, to_user_id, status, created_at, expires_at)
+	VALUES ($1,$2,$3,$4,'pending',NOW(),$5) RETURNING id`,
+		o.PollID, o.ChatID, o.FromUserID, o.ToUserID, o.ExpiresAt,
+	).Scan(&id)
+	return id, err
+}
+
+func (s *Repository) SetSwapOfferMessage(ctx context.Context, id int64, messageID int) error {
+	_, err := s.DB.Exec(ctx, `UPDATE swap_offers SET message_id=$2 WHERE id=$1`, id, messageID)
+	return err
+}
+
+func (s *Repository) GetSwapOffer(ctx context.Context, id int64) (*SwapOfferDTO, error) {
+	var o SwapOfferDTO
+	err := s.DB.QueryRow(ctx, `SELECT id, poll_id, chat_id, COALESCE(message_id, 0), from_user_id, to_user_id, status, created_at, expires_at
+	FROM swap_offers WHERE id=$1`, id).
+		Scan(&o.ID, &o.PollID, &o.ChatID, &o.MessageID, &o.FromUserID, &o.ToUserID, &o.Status, &o.CreatedAt, &o.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// CloseSwapOffer moves a pending offer to the given final status. It returns
+// ErrSwapOfferClosed if the offer is not pending anymore.
+func (s *Repository) CloseSwapOffer(ctx context.Context, id int64, status string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE swap_offers SET status=$2 WHERE id=$1 AND status='pending'`, id, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSwapOfferClosed
+	}
+	return nil
+}
+
+// AcceptSwapOffer exchanges the queue positions of both users and marks the
+// offer accepted in a single transaction. An offer past its expiry is marked
+// expired and ErrSwapOfferClosed is returned.
+func (s *Repository) AcceptSwapOffer(ctx context.Context, id int64) (*SwapOfferDTO, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var o SwapOfferDTO
+	err = tx.QueryRow(ctx, `SELECT id, poll_id, chat_id, COALESCE(message_id, 0), from_user_id, to_user_id, status, created_at, expires_at
+	FROM swap_offers WHERE id=$1 FOR UPDATE`, id).
+		Scan(&o.ID, &o.PollID, &o.ChatID, &o.MessageID, &o.FromUserID, &o.ToUserID, &o.Status, &o.CreatedAt, &o.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != SwapStatusPending {
+		return nil, ErrSwapOfferClosed
+	}
+	if o.ExpiresAt.Before(time.Now()) {
+		// The expiry job is late or gone; close the offer here instead
+		if _, err := tx.Exec(ctx, `UPDATE swap_offers SET status='expired' WHERE id=$1`, id); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		return nil, ErrSwapOfferClosed
+	}
+
+	if err := lockQueue(ctx, tx, o.PollID); err != nil {
+		return nil, err
+	}
+	var fromPos, toPos int
+	err = tx.QueryRow(ctx, `SELECT position FROM queue_entries WHERE poll_id=$1 AND user_id=$2 AND left_at IS NULL AND status='waiting'`, o.PollID, o.FromUserID).Scan(&fromPos)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSwapNotPossible
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(ctx, `SELECT position FROM queue_entries WHERE poll_id=$1 AND user_id=$2 AND left_at IS NULL AND status='waiting'`, o.PollID, o.ToUserID).Scan(&toPos)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSwapNotPossible
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE queue_entries SET position = CASE user_id WHEN $2 THEN $5::INT ELSE $4::INT END
+	WHERE poll_id=$1 AND left_at IS NULL AND user_id IN ($2, $3)`, o.PollID, o.FromUserID, o.ToUserID, fromPos, toPos)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE swap_offers SET status='accepted' WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	o.Status = SwapStatusAccepted
+	return &o, tx.Commit(ctx)
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS queue_entries;
\ No newline at end of file
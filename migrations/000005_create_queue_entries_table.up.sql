@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS queue_entries
+(
+    id        BIGSERIAL PRIMARY KEY,
+    poll_id   TEXT        NOT NULL,
+    user_id   BIGINT      NOT NULL,
+    position  INT         NOT NULL,
+    joined_at TIMESTAMPTZ NOT NULL,
+    left_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS queue_entries_active_user_idx ON queue_entries (poll_id, user_id) WHERE left_at IS NULL;
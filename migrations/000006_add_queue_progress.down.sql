@@ -0,0 +1,2 @@
+ALTER TABLE queue_entries DROP COLUMN IF EXISTS status;
+ALTER TABLE polls DROP COLUMN IF EXISTS current_position;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS current_position INT NOT NULL DEFAULT 1;
+
+ALTER TABLE queue_entries
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'waiting';
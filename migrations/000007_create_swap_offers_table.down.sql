@@ -0,0 +1 @@
+DROP TABLE IF EXISTS swap_offers;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS swap_offers
+(
+    id           BIGSERIAL PRIMARY KEY,
+    poll_id      TEXT        NOT NULL,
+    chat_id      BIGINT      NOT NULL,
+    message_id   INT,
+    from_user_id BIGINT      NOT NULL,
+    to_user_id   BIGINT      NOT NULL,
+    status       TEXT        NOT NULL DEFAULT 'pending',
+    created_at   TIMESTAMPTZ NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL
+);
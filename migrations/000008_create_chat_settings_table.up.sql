@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS chat_settings
+(
+    chat_id         BIGINT PRIMARY KEY,
+    lineup_strategy TEXT        NOT NULL DEFAULT 'uniform',
+    updated_at      TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,10 @@
+ALTER TABLE poll_results
+    DROP COLUMN IF EXISTS seed,
+    DROP COLUMN IF EXISTS seed_secret,
+    DROP COLUMN IF EXISTS strategy,
+    DROP COLUMN IF EXISTS input_user_ids,
+    DROP COLUMN IF EXISTS weights,
+    DROP COLUMN IF EXISTS lineup_user_ids;
+ALTER TABLE polls
+    DROP COLUMN IF EXISTS seed_secret,
+    DROP COLUMN IF EXISTS seed_commitment;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS seed_secret     TEXT,
+    ADD COLUMN IF NOT EXISTS seed_commitment TEXT;
+
+ALTER TABLE poll_results
+    ADD COLUMN IF NOT EXISTS seed            BIGINT,
+    ADD COLUMN IF NOT EXISTS seed_secret     TEXT,
+    ADD COLUMN IF NOT EXISTS strategy        TEXT,
+    ADD COLUMN IF NOT EXISTS input_user_ids  BIGINT[],
+    ADD COLUMN IF NOT EXISTS weights         FLOAT8[],
+    ADD COLUMN IF NOT EXISTS lineup_user_ids BIGINT[];
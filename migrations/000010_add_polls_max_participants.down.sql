@@ -0,0 +1 @@
+ALTER TABLE polls DROP COLUMN IF EXISTS max_participants;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS max_participants INT NOT NULL DEFAULT 0;
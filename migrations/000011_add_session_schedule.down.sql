@@ -0,0 +1,5 @@
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS timezone;
+ALTER TABLE polls
+    DROP COLUMN IF EXISTS session_start_at,
+    DROP COLUMN IF EXISTS slot_seconds,
+    DROP COLUMN IF EXISTS current_started_at;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS session_start_at   TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS slot_seconds       INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS current_started_at TIMESTAMPTZ;
+
+ALTER TABLE chat_settings
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'Europe/Moscow';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS poll_schedules;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS poll_schedules
+(
+    id               BIGSERIAL PRIMARY KEY,
+    chat_id          BIGINT      NOT NULL,
+    topic            TEXT        NOT NULL,
+    duration_seconds INT         NOT NULL,
+    max_participants INT         NOT NULL DEFAULT 0,
+    weekday          SMALLINT    NOT NULL,
+    start_minute     INT         NOT NULL,
+    timezone         TEXT        NOT NULL,
+    creator_id       BIGINT      NOT NULL,
+    creator_username TEXT,
+    creator_name     TEXT,
+    paused           BOOLEAN     NOT NULL DEFAULT FALSE,
+    next_run_at      TIMESTAMPTZ NOT NULL,
+    last_run_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS poll_schedules_due_idx ON poll_schedules (next_run_at) WHERE NOT paused;
+CREATE INDEX IF NOT EXISTS poll_schedules_chat_idx ON poll_schedules (chat_id);
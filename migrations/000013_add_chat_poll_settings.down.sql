@@ -0,0 +1,8 @@
+ALTER TABLE chat_settings
+    DROP COLUMN IF EXISTS topics,
+    DROP COLUMN IF EXISTS duration_presets,
+    DROP COLUMN IF EXISTS default_duration_seconds,
+    DROP COLUMN IF EXISTS min_duration_seconds,
+    DROP COLUMN IF EXISTS max_duration_seconds,
+    DROP COLUMN IF EXISTS option_coming,
+    DROP COLUMN IF EXISTS option_not_coming;
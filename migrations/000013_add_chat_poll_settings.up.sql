@@ -0,0 +1,8 @@
+ALTER TABLE chat_settings
+    ADD COLUMN IF NOT EXISTS topics                   TEXT[],
+    ADD COLUMN IF NOT EXISTS duration_presets         INT[],
+    ADD COLUMN IF NOT EXISTS default_duration_seconds INT,
+    ADD COLUMN IF NOT EXISTS min_duration_seconds     INT  NOT NULL DEFAULT 60,
+    ADD COLUMN IF NOT EXISTS max_duration_seconds     INT  NOT NULL DEFAULT 604800,
+    ADD COLUMN IF NOT EXISTS option_coming            TEXT NOT NULL DEFAULT 'Иду',
+    ADD COLUMN IF NOT EXISTS option_not_coming        TEXT NOT NULL DEFAULT 'Не иду';
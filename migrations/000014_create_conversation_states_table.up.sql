@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS conversation_states
+(
+    chat_id    BIGINT      NOT NULL,
+    user_id    BIGINT      NOT NULL,
+    flow       TEXT        NOT NULL,
+    data       JSONB       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, user_id, flow)
+);
+
+CREATE INDEX IF NOT EXISTS conversation_states_expires_at_idx ON conversation_states (expires_at);
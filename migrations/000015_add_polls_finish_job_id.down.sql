@@ -0,0 +1,2 @@
+ALTER TABLE polls
+    DROP COLUMN IF EXISTS finish_job_id;
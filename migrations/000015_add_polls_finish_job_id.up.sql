@@ -0,0 +1,2 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS finish_job_id BIGINT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS processed_updates;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS processed_updates
+(
+    update_id   BIGINT PRIMARY KEY,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS processed_updates_received_at_idx ON processed_updates (received_at);
@@ -0,0 +1,4 @@
+ALTER TABLE chat_settings
+    DROP COLUMN IF EXISTS poll_creator_role;
+
+DROP TABLE IF EXISTS chat_roles;
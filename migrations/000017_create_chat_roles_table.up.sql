@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS chat_roles
+(
+    chat_id    BIGINT      NOT NULL,
+    user_id    BIGINT      NOT NULL,
+    username   TEXT,
+    name       TEXT,
+    role       TEXT        NOT NULL,
+    granted_by BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, user_id)
+);
+
+ALTER TABLE chat_settings
+    ADD COLUMN IF NOT EXISTS poll_creator_role TEXT NOT NULL DEFAULT 'member';
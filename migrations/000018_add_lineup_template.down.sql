@@ -0,0 +1,2 @@
+ALTER TABLE chat_settings
+    DROP COLUMN IF EXISTS lineup_template;
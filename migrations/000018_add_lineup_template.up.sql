@@ -0,0 +1,2 @@
+ALTER TABLE chat_settings
+    ADD COLUMN IF NOT EXISTS lineup_template TEXT NOT NULL DEFAULT '';
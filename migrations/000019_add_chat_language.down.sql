@@ -0,0 +1,10 @@
+UPDATE chat_settings
+SET option_coming = 'Иду', option_not_coming = 'Не иду'
+WHERE option_coming = '' AND option_not_coming = '';
+
+ALTER TABLE chat_settings
+    ALTER COLUMN option_coming SET DEFAULT 'Иду',
+    ALTER COLUMN option_not_coming SET DEFAULT 'Не иду';
+
+ALTER TABLE chat_settings
+    DROP COLUMN IF EXISTS language;
@@ -0,0 +1,11 @@
+ALTER TABLE chat_settings
+    ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+
+-- Empty option labels are the defaults in the chat's language
+ALTER TABLE chat_settings
+    ALTER COLUMN option_coming SET DEFAULT '',
+    ALTER COLUMN option_not_coming SET DEFAULT '';
+
+UPDATE chat_settings
+SET option_coming = '', option_not_coming = ''
+WHERE option_coming = 'Иду' AND option_not_coming = 'Не иду';
@@ -0,0 +1,2 @@
+ALTER TABLE polls
+    ADD COLUMN IF NOT EXISTS reconcile_attempts INT NOT NULL DEFAULT 0;